@@ -11,11 +11,17 @@ import "os"
 func AutoLoad(defaultEnvContent string) error {
 	return autoLoad(
 		defaultEnvContent,
-		resolveSelector(os.Getenv("ENV")),
+		Selector(),
 		NewLoader(),
 	)
 }
 
+// Selector returns the environment selector taken from the ENV environment variable.
+// If the ENV environment variable is not set, it defaults to "dev".
+func Selector() string {
+	return resolveSelector(os.Getenv("ENV"))
+}
+
 func autoLoad(defaultEnvContent, selector string, loader *Loader) error {
 	files := []string{
 		".env." + selector + ".local",
@@ -72,6 +72,20 @@ func Test_autoLoad(t *testing.T) {
 	})
 }
 
+func TestSelector(t *testing.T) {
+	t.Run("specified", func(t *testing.T) {
+		t.Setenv("ENV", "prod")
+
+		require.Equal(t, "prod", Selector())
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("ENV", "")
+
+		require.Equal(t, "dev", Selector())
+	})
+}
+
 func Test_resolveSelector(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,204 @@
+package env
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// kubernetesDataDir is the symlink Kubernetes atomically swaps when a mounted
+// ConfigMap or Secret is updated.
+const kubernetesDataDir = "..data"
+
+// Dir is a Source reading a directory tree where each file name is a key and
+// the file content is the value. Files in nested directories are keyed by
+// their path, with path elements joined by an underscore.
+//
+// Hidden entries (starting with a dot) are skipped, which makes Dir compatible
+// with the layout Kubernetes uses for mounted ConfigMaps and Secrets: each key
+// is a symlink into the "..data" directory, which itself is a symlink to a
+// timestamped directory swapped atomically on update.
+type Dir struct {
+	root     string
+	prefix   string
+	readFile func(path string) ([]byte, error)
+}
+
+// DirOption configures a Dir.
+type DirOption func(d *Dir)
+
+// WithKeyPrefix prepends the prefix to every key read from the directory.
+func WithKeyPrefix(prefix string) DirOption {
+	return func(d *Dir) {
+		d.prefix = prefix
+	}
+}
+
+// NewDir creates a new Dir source reading the directory tree at root.
+func NewDir(root string, opts ...DirOption) *Dir {
+	d := &Dir{
+		root:     root,
+		readFile: os.ReadFile,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// String implements Stringer interface.
+func (d *Dir) String() string {
+	return "dir:" + d.root
+}
+
+// Values implements Source. A single trailing newline is removed from each value.
+func (d *Dir) Values() (map[string]string, error) {
+	values := make(map[string]string)
+
+	if err := d.read(d.root, d.prefix, values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// Version returns a token which changes whenever the directory content changes.
+// For the Kubernetes layout, it is the target of the "..data" symlink. Otherwise,
+// it is a checksum of all keys and values.
+func (d *Dir) Version() (string, error) {
+	target, err := os.Readlink(filepath.Join(d.root, kubernetesDataDir))
+	if err == nil {
+		return target, nil
+	}
+
+	values, err := d.Values()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		_, _ = fmt.Fprintf(hash, "%s=%q\n", key, values[key])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Watch polls the directory at the given interval and calls onChange with the
+// new values whenever the version changes. Values are read consistently, i.e.
+// the read is retried if the directory was swapped while reading.
+// It blocks until the context is cancelled. Read errors are ignored while watching,
+// since they are expected to be transient during an update.
+func (d *Dir) Watch(ctx context.Context, interval time.Duration, onChange func(values map[string]string)) error {
+	version, err := d.Version()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		values, current, err := d.snapshot()
+		if err != nil || current == version {
+			continue
+		}
+
+		version = current
+
+		onChange(values)
+	}
+}
+
+// snapshot reads the values along with the version they belong to.
+func (d *Dir) snapshot() (map[string]string, string, error) {
+	const maxAttempts = 3
+
+	for range maxAttempts {
+		before, err := d.Version()
+		if err != nil {
+			return nil, "", err
+		}
+
+		values, err := d.Values()
+		if err != nil {
+			return nil, "", err
+		}
+
+		after, err := d.Version()
+		if err != nil {
+			return nil, "", err
+		}
+
+		if before == after {
+			return values, after, nil
+		}
+	}
+
+	return nil, "", errors.New("directory keeps changing while reading")
+}
+
+func (d *Dir) read(dir, prefix string, values map[string]string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+
+		// follow symlinks, so entries pointing into "..data" are resolved
+		info, err := os.Stat(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// dangling symlink, possibly in the middle of an update
+				continue
+			}
+
+			return fmt.Errorf("failed to stat file: %w", err)
+		}
+
+		if info.IsDir() {
+			if err := d.read(path, prefix+name+"_", values); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		content, err := d.readFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		value := strings.TrimSuffix(string(content), "\n")
+		value = strings.TrimSuffix(value, "\r")
+
+		values[prefix+name] = value
+	}
+
+	return nil
+}
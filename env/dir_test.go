@@ -0,0 +1,167 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeKubernetesDir creates the layout Kubernetes uses for mounted volumes:
+// keys are symlinks into "..data", which points to a timestamped directory.
+func writeKubernetesDir(t *testing.T, root, version string, values map[string]string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, version), 0o755))
+
+	for key, value := range values {
+		require.NoError(t, os.WriteFile(filepath.Join(root, version, key), []byte(value), 0o600))
+
+		link := filepath.Join(root, key)
+		if _, err := os.Lstat(link); err != nil {
+			require.NoError(t, os.Symlink(filepath.Join(kubernetesDataDir, key), link))
+		}
+	}
+
+	// swap atomically, as kubelet does
+	tmpLink := filepath.Join(root, "..data_tmp")
+
+	require.NoError(t, os.Symlink(version, tmpLink))
+	require.NoError(t, os.Rename(tmpLink, filepath.Join(root, kubernetesDataDir)))
+}
+
+func TestDir_Values(t *testing.T) {
+	t.Parallel()
+
+	t.Run("plain", func(t *testing.T) {
+		t.Parallel()
+
+		root := t.TempDir()
+
+		require.NoError(t, os.WriteFile(filepath.Join(root, "KEY1"), []byte("value1\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(root, ".hidden"), []byte("hidden"), 0o600))
+		require.NoError(t, os.Mkdir(filepath.Join(root, "DB"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "DB", "PASSWORD"), []byte("secret"), 0o600))
+
+		values, err := NewDir(root).Values()
+
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"KEY1": "value1", "DB_PASSWORD": "secret"}, values)
+	})
+
+	t.Run("kubernetes", func(t *testing.T) {
+		t.Parallel()
+
+		root := t.TempDir()
+
+		writeKubernetesDir(t, root, "..2024_01_01", map[string]string{"KEY1": "value1", "KEY2": "value2"})
+
+		values, err := NewDir(root, WithKeyPrefix("APP_")).Values()
+
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"APP_KEY1": "value1", "APP_KEY2": "value2"}, values)
+	})
+
+	t.Run("missing-dir", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewDir(filepath.Join(t.TempDir(), "missing")).Values()
+
+		require.Error(t, err)
+	})
+
+	t.Run("read-error", func(t *testing.T) {
+		t.Parallel()
+
+		root := t.TempDir()
+
+		require.NoError(t, os.WriteFile(filepath.Join(root, "KEY1"), []byte("value1"), 0o600))
+
+		forcedError := errors.New("forced-error")
+
+		dir := NewDir(root)
+		dir.readFile = func(path string) ([]byte, error) {
+			return nil, forcedError
+		}
+
+		_, err := dir.Values()
+
+		require.ErrorIs(t, err, forcedError)
+	})
+}
+
+func TestDir_Version(t *testing.T) {
+	t.Parallel()
+
+	t.Run("plain", func(t *testing.T) {
+		t.Parallel()
+
+		root := t.TempDir()
+		file := filepath.Join(root, "KEY1")
+
+		require.NoError(t, os.WriteFile(file, []byte("value1"), 0o600))
+
+		v1, err := NewDir(root).Version()
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(file, []byte("value2"), 0o600))
+
+		v2, err := NewDir(root).Version()
+		require.NoError(t, err)
+
+		require.NotEqual(t, v1, v2)
+	})
+
+	t.Run("kubernetes", func(t *testing.T) {
+		t.Parallel()
+
+		root := t.TempDir()
+
+		writeKubernetesDir(t, root, "..2024_01_01", map[string]string{"KEY1": "value1"})
+
+		version, err := NewDir(root).Version()
+
+		require.NoError(t, err)
+		require.Equal(t, "..2024_01_01", version)
+	})
+}
+
+func TestDir_Watch(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	writeKubernetesDir(t, root, "..2024_01_01", map[string]string{"KEY1": "value1"})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	changes := make(chan map[string]string, 1)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- NewDir(root).Watch(ctx, 10*time.Millisecond, func(values map[string]string) {
+			changes <- values
+		})
+	}()
+
+	// let the watcher capture the initial version
+	time.Sleep(50 * time.Millisecond)
+
+	writeKubernetesDir(t, root, "..2024_01_02", map[string]string{"KEY1": "value2"})
+
+	select {
+	case values := <-changes:
+		require.Equal(t, map[string]string{"KEY1": "value2"}, values)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for change")
+	}
+
+	cancel()
+
+	require.NoError(t, <-errCh)
+}
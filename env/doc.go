@@ -11,4 +11,8 @@
 // .env.local files, loading variables from them if they are found. This
 // functionality allows for environment-specific variables to be set,
 // thereby enhancing the flexibility of the environment configuration.
+//
+// Variables can also be loaded from a Source, such as Dir, which reads
+// a directory with one file per key, the way Kubernetes mounts ConfigMaps
+// and Secrets.
 package env
@@ -0,0 +1,49 @@
+package env
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Source provides environment variables from a location other than the process environment.
+type Source interface {
+	// Values returns all key-value pairs provided by the source.
+	Values() (map[string]string, error)
+}
+
+// LoadSource loads the environment variables from the given source.
+// Existing environment variables are not overridden.
+func (l *Loader) LoadSource(src Source) error {
+	values, err := src.Values()
+	if err != nil {
+		return fmt.Errorf("failed to read source: %w", err)
+	}
+
+	return applyValues(values, l.lookuper, l.setter)
+}
+
+// applyValues sets the environment variables from the given key-value pairs.
+func applyValues(
+	values map[string]string,
+	lookuper func(string) (string, bool),
+	setter func(key, value string) error,
+) error {
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		value := values[key]
+		if key == "" || value == "" {
+			continue
+		}
+
+		// do not override existing variables
+		if _, exists := lookuper(key); exists {
+			continue
+		}
+
+		if err := setter(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
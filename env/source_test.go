@@ -0,0 +1,80 @@
+package env
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type staticSource struct {
+	values map[string]string
+	err    error
+}
+
+func (s staticSource) Values() (map[string]string, error) {
+	return s.values, s.err
+}
+
+func TestLoader_LoadSource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		envs := map[string]string{"EXISTING": "existing"}
+
+		envLoader := NewLoader()
+
+		envLoader.lookuper = func(key string) (string, bool) {
+			value, ok := envs[key]
+
+			return value, ok
+		}
+
+		envLoader.setter = func(key, value string) error {
+			envs[key] = value
+
+			return nil
+		}
+
+		err := envLoader.LoadSource(staticSource{values: map[string]string{
+			"KEY1":     "value1",
+			"EMPTY":    "",
+			"EXISTING": "new",
+		}})
+
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"KEY1": "value1", "EXISTING": "existing"}, envs)
+	})
+
+	t.Run("source-error", func(t *testing.T) {
+		t.Parallel()
+
+		forcedError := errors.New("forced-error")
+
+		err := NewLoader().LoadSource(staticSource{err: forcedError})
+
+		require.ErrorIs(t, err, forcedError)
+	})
+
+	t.Run("setter-error", func(t *testing.T) {
+		t.Parallel()
+
+		forcedError := errors.New("forced-error")
+
+		envLoader := NewLoader()
+
+		envLoader.lookuper = func(key string) (string, bool) {
+			return "", false
+		}
+
+		envLoader.setter = func(key, value string) error {
+			return forcedError
+		}
+
+		err := envLoader.LoadSource(staticSource{values: map[string]string{"KEY1": "value1"}})
+
+		require.ErrorIs(t, err, forcedError)
+	})
+}
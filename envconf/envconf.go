@@ -4,37 +4,117 @@
 //
 // Parser also recognizes following custom formats:
 //   - timex.Duration
+//
+// Fields are bound to variables with `env` tags. The tag may contain options:
+//   - required: the variable has to be set
+//   - notEmpty: the variable has to be set to a non-empty value
+//   - unset: the variable is removed from the environment once parsed
+//   - file: the variable holds a path to a file containing the value
+//   - expand: references to other variables, e.g. ${HOME}, are expanded
+//   - secret: the value is redacted in configuration snapshots
+//
+// Supported field types are strings, booleans, numbers, time.Duration, timex.Duration,
+// url.URL, types implementing encoding.TextUnmarshaler, pointers to these types,
+// slices (delimited by `envSeparator`, defaults to ",") and maps (pairs delimited by
+// `envSeparator`, key and value delimited by `envKeyValSeparator`, defaults to ":").
+//
+// Nested structs are parsed recursively, and their variables may be prefixed with `envPrefix`:
+//
+//	HTTPD httpd.Config `envPrefix:"ADMIN_"`
+//
+// Defaults may depend on the active profile, selected by the ENV environment
+// variable the same way env.AutoLoad does it:
+//
+//	LogFormat string `env:"LOG_FORMAT" envDefault:"console" envDefault.prod:"json"`
+//
+// A field may be required only when another variable has a specific value:
+//
+//	CertFile string `env:"TLS_CERT_FILE" envRequiredIf:"TLS_ENABLED"`
+//	Password string `env:"DB_PASSWORD" envRequiredIf:"DB_DRIVER=postgres|mysql"`
 package envconf
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"reflect"
 	"strconv"
+	"strings"
 
-	envs "github.com/caarlos0/env/v7"
-	"github.com/exopulse/go-kit/timex"
+	"github.com/exopulse/go-kit/env"
 )
 
 // Parse parses a struct containing `env` tags and loads its values from environment variables.
-//
-//nolint:wrapcheck // no need to wrap these errors
-func Parse(v any) error {
-	return envs.ParseWithFuncs(v, customParsers())
+// All fields are parsed, and the errors are returned joined together as FieldError values.
+func Parse(v any, opts ...Option) error {
+	o := newOptions(opts)
+
+	environment, err := environ(o.sources)
+	if err != nil {
+		return err
+	}
+
+	p := &parser{
+		env:     environment,
+		profile: o.profile,
+	}
+
+	err = p.parse(v)
+	if o.provenance != nil && !errors.Is(err, ErrNotStructPtr) {
+		// the sources are recorded now, as the environment may change before the snapshot is taken
+		*o.provenance = Provenance{typ: reflect.TypeOf(v).Elem(), fields: p.records}
+	}
+
+	return err
+}
+
+func parseBool(v string) (bool, error) {
+	switch v {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	default:
+		return strconv.ParseBool(v)
+	}
 }
 
-func customParsers() map[reflect.Type]envs.ParserFunc {
-	return map[reflect.Type]envs.ParserFunc{
-		reflect.TypeOf(true): func(v string) (any, error) {
-			switch v {
-			case "on", "yes":
-				return true, nil
-			case "off", "no":
-				return false, nil
-			default:
-				return strconv.ParseBool(v)
+// environ returns a snapshot of the process environment, extended with values from the sources.
+func environ(sources []env.Source) (environment, error) {
+	environment := environment{
+		values:  make(map[string]string),
+		origins: make(map[string]string),
+	}
+
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			environment.values[key] = value
+			environment.origins[key] = originEnv
+		}
+	}
+
+	for _, src := range sources {
+		values, err := src.Values()
+		if err != nil {
+			return environment, fmt.Errorf("failed to read source: %w", err)
+		}
+
+		for key, value := range values {
+			if environment.values[key] == "" {
+				environment.values[key] = value
+				environment.origins[key] = sourceName(src)
 			}
-		},
-		reflect.TypeOf(timex.Duration(0)): func(v string) (any, error) {
-			return timex.ParseDuration(v)
-		},
+		}
 	}
+
+	return environment, nil
+}
+
+// sourceName returns the name of the source used to report the origin of a value.
+func sourceName(src env.Source) string {
+	if s, ok := src.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	return originSource
 }
@@ -0,0 +1,53 @@
+package envconf
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotStructPtr is returned when the parsed value is not a pointer to a struct.
+	ErrNotStructPtr = errors.New("expected a pointer to a struct")
+
+	// ErrNotParsed is returned when taking a snapshot with a provenance not recorded by Parse
+	// for the type of the struct.
+	ErrNotParsed = errors.New("provenance not recorded for the struct")
+
+	// ErrNotSet is returned when a required environment variable is not set.
+	ErrNotSet = errors.New("required environment variable is not set")
+
+	// ErrEmpty is returned when an environment variable marked as notEmpty is empty.
+	ErrEmpty = errors.New("environment variable should not be empty")
+
+	// ErrUnsupportedType is returned when there is no parser for the field type.
+	ErrUnsupportedType = errors.New("unsupported field type")
+
+	// ErrUnsupportedOption is returned when the env tag contains an unknown option.
+	ErrUnsupportedOption = errors.New("unsupported tag option")
+)
+
+// FieldError describes a failure to parse a single struct field.
+// Parse returns all field errors joined together, so they can be inspected with errors.As.
+type FieldError struct {
+	// Field is the path of the struct field, e.g. "HTTPD.Port".
+	Field string
+
+	// Key is the environment variable bound to the field.
+	Key string
+
+	Err error
+}
+
+// Error implements error interface.
+func (e *FieldError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s: %v", e.Field, e.Err)
+	}
+
+	return fmt.Sprintf("%s (%s): %v", e.Key, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,48 @@
+package envconf
+
+import "github.com/exopulse/go-kit/env"
+
+// Option configures the parser.
+type Option func(o *options)
+
+type options struct {
+	profile    string
+	sources    []env.Source
+	provenance *Provenance
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		profile: env.Selector(),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithProfile selects the profile used to resolve profile-aware defaults.
+// If not specified, the profile is selected by the ENV environment variable.
+func WithProfile(profile string) Option {
+	return func(o *options) {
+		o.profile = profile
+	}
+}
+
+// WithSource adds a source of environment variables, e.g. env.Dir.
+// Variables set in the process environment take precedence over the sources.
+// Sources are consulted in order in which they are specified.
+func WithSource(src env.Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, src)
+	}
+}
+
+// WithProvenance records the sources of the parsed values into p, for TakeSnapshot.
+func WithProvenance(p *Provenance) Option {
+	return func(o *options) {
+		o.provenance = p
+	}
+}
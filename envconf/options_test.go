@@ -0,0 +1,37 @@
+package envconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/exopulse/go-kit/env"
+	. "github.com/stretchr/testify/require"
+)
+
+type sourceConf struct {
+	Name  string `env:"SOURCE_NAME"`
+	Token string `env:"SOURCE_TOKEN"`
+}
+
+func TestParse_WithSource(t *testing.T) {
+	root := t.TempDir()
+
+	NoError(t, os.WriteFile(filepath.Join(root, "NAME"), []byte("from-dir\n"), 0o600))
+	NoError(t, os.WriteFile(filepath.Join(root, "TOKEN"), []byte("token"), 0o600))
+
+	t.Setenv("SOURCE_NAME", "from-env")
+	t.Setenv("SOURCE_TOKEN", "")
+
+	cf := sourceConf{}
+
+	NoError(t, Parse(&cf, WithSource(env.NewDir(root, env.WithKeyPrefix("SOURCE_")))))
+	Equal(t, "from-env", cf.Name)
+	Equal(t, "token", cf.Token)
+}
+
+func TestParse_WithSource_Error(t *testing.T) {
+	cf := sourceConf{}
+
+	Error(t, Parse(&cf, WithSource(env.NewDir(filepath.Join(t.TempDir(), "missing")))))
+}
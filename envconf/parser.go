@@ -0,0 +1,505 @@
+package envconf
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/exopulse/go-kit/timex"
+)
+
+const (
+	tagEnv             = "env"
+	tagDefault         = "envDefault"
+	tagPrefix          = "envPrefix"
+	tagSeparator       = "envSeparator"
+	tagKeyValSeparator = "envKeyValSeparator"
+	tagExpand          = "envExpand"
+	tagRequiredIf      = "envRequiredIf"
+
+	defaultSeparator       = ","
+	defaultKeyValSeparator = ":"
+)
+
+const (
+	originEnv     = "env"
+	originSource  = "source"
+	originDefault = "default"
+)
+
+//nolint:gochecknoglobals // read-only lookup tables
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+	typeParsers = map[reflect.Type]func(v string) (any, error){
+		reflect.TypeFor[bool](): func(v string) (any, error) {
+			return parseBool(v)
+		},
+		reflect.TypeFor[timex.Duration](): func(v string) (any, error) {
+			return timex.ParseDuration(v)
+		},
+		reflect.TypeFor[time.Duration](): func(v string) (any, error) {
+			return time.ParseDuration(v)
+		},
+		reflect.TypeFor[url.URL](): func(v string) (any, error) {
+			u, err := url.Parse(v)
+			if err != nil {
+				return nil, err
+			}
+
+			return *u, nil
+		},
+	}
+)
+
+// environment holds the variables available to the parser along with their origin.
+type environment struct {
+	values  map[string]string
+	origins map[string]string
+}
+
+func (e environment) lookup(key string) (string, bool) {
+	value, ok := e.values[key]
+
+	return value, ok
+}
+
+// resolved is a field bound to an environment variable, along with the value it was resolved to.
+type resolved struct {
+	path   string
+	key    string
+	value  string
+	origin string
+	secret bool
+	tag    reflect.StructTag
+}
+
+// explicit reports whether the value was set explicitly, rather than taken from the default.
+func (r resolved) explicit() bool {
+	return r.origin != "" && !strings.HasPrefix(r.origin, originDefault)
+}
+
+// tagOptions contains options specified in the env tag, e.g. `env:"KEY,required,notEmpty"`.
+type tagOptions struct {
+	required bool
+	notEmpty bool
+	unset    bool
+	file     bool
+	expand   bool
+	secret   bool
+}
+
+type parser struct {
+	env     environment
+	profile string
+	fields  []resolved
+	errs    []error
+
+	// records holds all resolved fields, including the ones of nested structs left nil,
+	// so snapshots report them as not configured.
+	records []resolved
+}
+
+// parse loads values into v, which has to be a pointer to a struct.
+func (p *parser) parse(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrNotStructPtr
+	}
+
+	p.parseStruct(rv.Elem(), "", "")
+	p.checkRequiredIf()
+
+	return errors.Join(p.errs...)
+}
+
+func (p *parser) parseStruct(v reflect.Value, prefix, path string) {
+	t := v.Type()
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		fieldPath := sf.Name
+		if path != "" {
+			fieldPath = path + "." + sf.Name
+		}
+
+		if isNested(sf.Type) {
+			p.parseNested(v.Field(i), prefix+sf.Tag.Get(tagPrefix), fieldPath)
+
+			continue
+		}
+
+		p.parseField(v.Field(i), sf, prefix, fieldPath)
+	}
+}
+
+// parseNested parses a nested struct. A nil pointer to a struct is allocated
+// only if at least one of its variables is set explicitly.
+func (p *parser) parseNested(v reflect.Value, prefix, path string) {
+	if v.Kind() != reflect.Pointer {
+		p.parseStruct(v, prefix, path)
+
+		return
+	}
+
+	if !v.IsNil() {
+		p.parseStruct(v.Elem(), prefix, path)
+
+		return
+	}
+
+	fields, errs, records := len(p.fields), len(p.errs), len(p.records)
+	nested := reflect.New(v.Type().Elem())
+
+	p.parseStruct(nested.Elem(), prefix, path)
+
+	for _, f := range p.fields[fields:] {
+		if f.explicit() {
+			v.Set(nested)
+
+			return
+		}
+	}
+
+	// nothing is configured, leave the pointer nil, and report its fields as not configured
+	p.errs = p.errs[:errs]
+	p.fields = p.fields[:fields]
+
+	for i := range p.records[records:] {
+		p.records[records+i].origin = ""
+	}
+}
+
+func (p *parser) parseField(v reflect.Value, sf reflect.StructField, prefix, path string) {
+	name, opts, err := parseTag(sf.Tag)
+	if err != nil {
+		p.fail(path, prefix+name, err)
+
+		return
+	}
+
+	key := ""
+	if name != "" {
+		key = prefix + name
+	}
+
+	value, origin, exists := p.lookup(key, sf.Tag)
+
+	if opts.expand {
+		value = os.Expand(value, func(name string) string {
+			v, _ := p.env.lookup(name)
+
+			return v
+		})
+	}
+
+	field := resolved{
+		path:   path,
+		key:    key,
+		value:  value,
+		origin: origin,
+		secret: opts.secret,
+		tag:    sf.Tag,
+	}
+
+	p.fields = append(p.fields, field)
+	p.records = append(p.records, field)
+
+	if opts.unset && key != "" {
+		_ = os.Unsetenv(key)
+	}
+
+	if opts.required && !exists && key != "" {
+		p.fail(path, key, ErrNotSet)
+
+		return
+	}
+
+	if opts.notEmpty && value == "" {
+		p.fail(path, key, ErrEmpty)
+
+		return
+	}
+
+	if opts.file && value != "" {
+		content, err := os.ReadFile(value)
+		if err != nil {
+			p.fail(path, key, fmt.Errorf("failed to load file content: %w", err))
+
+			return
+		}
+
+		value = string(content)
+	}
+
+	if value == "" {
+		return
+	}
+
+	if err := set(v, value, sf.Tag); err != nil {
+		p.fail(path, key, err)
+	}
+}
+
+// lookup resolves the value of the variable. Empty variable is replaced by the default value, if one exists.
+// Profile specific default takes precedence over the generic one.
+func (p *parser) lookup(key string, tag reflect.StructTag) (string, string, bool) {
+	value, exists := p.env.lookup(key)
+	if exists && value != "" {
+		return value, p.env.origins[key], true
+	}
+
+	if p.profile != "" {
+		if def, ok := tag.Lookup(tagDefault + "." + p.profile); ok {
+			return def, originDefault + "." + p.profile, true
+		}
+	}
+
+	if def, ok := tag.Lookup(tagDefault); ok {
+		return def, originDefault, true
+	}
+
+	if exists {
+		return "", p.env.origins[key], true
+	}
+
+	return "", "", false
+}
+
+// checkRequiredIf validates conditional requirements declared with the envRequiredIf tag.
+// The condition is either "KEY", satisfied when KEY holds a true boolean value,
+// or "KEY=value", satisfied when KEY equals to one of the values delimited with "|".
+func (p *parser) checkRequiredIf() {
+	lookup := func(key string) string {
+		for _, f := range p.fields {
+			if f.key == key {
+				return f.value
+			}
+		}
+
+		value, _ := p.env.lookup(key)
+
+		return value
+	}
+
+	for _, f := range p.fields {
+		condition, ok := f.tag.Lookup(tagRequiredIf)
+		if !ok || f.value != "" || !conditionHolds(condition, lookup) {
+			continue
+		}
+
+		p.fail(f.path, f.key, fmt.Errorf("%w (required if %s)", ErrNotSet, condition))
+	}
+}
+
+func (p *parser) fail(path, key string, err error) {
+	p.errs = append(p.errs, &FieldError{Field: path, Key: key, Err: err})
+}
+
+func conditionHolds(condition string, lookup func(key string) string) bool {
+	key, expected, hasValue := strings.Cut(condition, "=")
+
+	actual := lookup(strings.TrimSpace(key))
+
+	if !hasValue {
+		b, err := parseBool(actual)
+
+		return err == nil && b
+	}
+
+	for _, value := range strings.Split(expected, "|") {
+		if actual == strings.TrimSpace(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseTag(tag reflect.StructTag) (string, tagOptions, error) {
+	name, rest, _ := strings.Cut(tag.Get(tagEnv), ",")
+
+	opts := tagOptions{
+		expand: strings.EqualFold(tag.Get(tagExpand), "true"),
+	}
+
+	if rest == "" {
+		return name, opts, nil
+	}
+
+	for _, opt := range strings.Split(rest, ",") {
+		switch opt {
+		case "":
+		case "required":
+			opts.required = true
+		case "notEmpty":
+			opts.notEmpty = true
+		case "unset":
+			opts.unset = true
+		case "file":
+			opts.file = true
+		case "expand":
+			opts.expand = true
+		case "secret":
+			opts.secret = true
+		default:
+			return name, opts, fmt.Errorf("%w: %q", ErrUnsupportedOption, opt)
+		}
+	}
+
+	return name, opts, nil
+}
+
+// isNested reports whether the type is a struct (or a pointer to a struct) to be parsed field by field.
+func isNested(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	if _, ok := typeParsers[t]; ok {
+		return false
+	}
+
+	return !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// set parses the value and stores it into v.
+func set(v reflect.Value, value string, tag reflect.StructTag) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+
+		if err := set(elem.Elem(), value, tag); err != nil {
+			return err
+		}
+
+		v.Set(elem)
+
+		return nil
+	}
+
+	if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(value))
+	}
+
+	if parse, ok := typeParsers[v.Type()]; ok {
+		parsed, err := parse(value)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(parsed))
+
+		return nil
+	}
+
+	return setKind(v, value, tag)
+}
+
+//nolint:cyclop // one case per kind
+func setKind(v reflect.Value, value string, tag reflect.StructTag) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+	case reflect.Slice:
+		return setSlice(v, value, tag)
+	case reflect.Map:
+		return setMap(v, value, tag)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+	}
+
+	return nil
+}
+
+func setSlice(v reflect.Value, value string, tag reflect.StructTag) error {
+	parts := strings.Split(value, separator(tag, tagSeparator, defaultSeparator))
+	slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+
+	for i, part := range parts {
+		if err := set(slice.Index(i), part, tag); err != nil {
+			return err
+		}
+	}
+
+	v.Set(slice)
+
+	return nil
+}
+
+func setMap(v reflect.Value, value string, tag reflect.StructTag) error {
+	kvSeparator := separator(tag, tagKeyValSeparator, defaultKeyValSeparator)
+	m := reflect.MakeMap(v.Type())
+
+	for _, part := range strings.Split(value, separator(tag, tagSeparator, defaultSeparator)) {
+		k, val, ok := strings.Cut(part, kvSeparator)
+		if !ok {
+			return fmt.Errorf(`%q should be in "key%svalue" format`, part, kvSeparator)
+		}
+
+		key := reflect.New(v.Type().Key()).Elem()
+		if err := set(key, k, tag); err != nil {
+			return err
+		}
+
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := set(elem, val, tag); err != nil {
+			return err
+		}
+
+		m.SetMapIndex(key, elem)
+	}
+
+	v.Set(m)
+
+	return nil
+}
+
+func separator(tag reflect.StructTag, name, defaultValue string) string {
+	if sep := tag.Get(name); sep != "" {
+		return sep
+	}
+
+	return defaultValue
+}
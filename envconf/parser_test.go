@@ -0,0 +1,279 @@
+package envconf
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/timex"
+	. "github.com/stretchr/testify/require"
+)
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("invalid level")
+	}
+
+	return nil
+}
+
+type nativeConf struct {
+	Int8     int8              `env:"NATIVE_INT8"`
+	Uint     uint              `env:"NATIVE_UINT"`
+	Float    float64           `env:"NATIVE_FLOAT"`
+	Duration time.Duration     `env:"NATIVE_DURATION"`
+	Timex    timex.Duration    `env:"NATIVE_TIMEX" envDefault:"1d"`
+	URL      url.URL           `env:"NATIVE_URL"`
+	Level    level             `env:"NATIVE_LEVEL"`
+	LevelPtr *level            `env:"NATIVE_LEVEL"`
+	IntPtr   *int              `env:"NATIVE_INT_PTR"`
+	Unset    *int              `env:"NATIVE_UNSET_PTR"`
+	Strings  []string          `env:"NATIVE_STRINGS" envSeparator:";"`
+	Bools    []bool            `env:"NATIVE_BOOLS"`
+	Levels   []level           `env:"NATIVE_LEVELS"`
+	Map      map[string]int    `env:"NATIVE_MAP"`
+	MapSep   map[string]string `env:"NATIVE_MAP_SEP" envKeyValSeparator:"="`
+	Expand   string            `env:"NATIVE_EXPAND,expand"`
+	Legacy   string            `env:"NATIVE_LEGACY" envExpand:"true"`
+	File     string            `env:"NATIVE_FILE,file"`
+	Server   struct {
+		Port string `env:"PORT" envDefault:"8080"`
+	} `envPrefix:"NATIVE_SERVER_"`
+	Admin *struct {
+		Port string `env:"PORT"`
+	} `envPrefix:"NATIVE_ADMIN_"`
+	Metrics *struct {
+		Port string `env:"PORT" envDefault:"9090"`
+	} `envPrefix:"NATIVE_METRICS_"`
+}
+
+func TestParse_Native(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+
+	NoError(t, os.WriteFile(file, []byte("content"), 0o600))
+
+	for key, value := range map[string]string{
+		"NATIVE_INT8":       "-8",
+		"NATIVE_UINT":       "42",
+		"NATIVE_FLOAT":      "1.5",
+		"NATIVE_DURATION":   "2m",
+		"NATIVE_URL":        "https://example.com/path",
+		"NATIVE_LEVEL":      "high",
+		"NATIVE_INT_PTR":    "7",
+		"NATIVE_STRINGS":    "a;b;c",
+		"NATIVE_BOOLS":      "on,no,true",
+		"NATIVE_LEVELS":     "low,high",
+		"NATIVE_MAP":        "a:1,b:2",
+		"NATIVE_MAP_SEP":    "a=x:y",
+		"NATIVE_NAME":       "world",
+		"NATIVE_EXPAND":     "hello ${NATIVE_NAME}",
+		"NATIVE_LEGACY":     "hi $NATIVE_NAME",
+		"NATIVE_FILE":       file,
+		"NATIVE_ADMIN_PORT": "8081",
+	} {
+		t.Setenv(key, value)
+	}
+
+	cf := nativeConf{}
+
+	NoError(t, Parse(&cf))
+
+	Equal(t, int8(-8), cf.Int8)
+	Equal(t, uint(42), cf.Uint)
+	InDelta(t, 1.5, cf.Float, 0)
+	Equal(t, 2*time.Minute, cf.Duration)
+	Equal(t, parseDuration("1d"), cf.Timex)
+	Equal(t, "example.com", cf.URL.Host)
+	Equal(t, level(2), cf.Level)
+	Equal(t, level(2), *cf.LevelPtr)
+	Equal(t, 7, *cf.IntPtr)
+	Nil(t, cf.Unset)
+	Equal(t, []string{"a", "b", "c"}, cf.Strings)
+	Equal(t, []bool{true, false, true}, cf.Bools)
+	Equal(t, []level{1, 2}, cf.Levels)
+	Equal(t, map[string]int{"a": 1, "b": 2}, cf.Map)
+	Equal(t, map[string]string{"a": "x:y"}, cf.MapSep)
+	Equal(t, "hello world", cf.Expand)
+	Equal(t, "hi world", cf.Legacy)
+	Equal(t, "content", cf.File)
+	Equal(t, "8080", cf.Server.Port)
+	NotNil(t, cf.Admin)
+	Equal(t, "8081", cf.Admin.Port)
+	Nil(t, cf.Metrics, "only defaults are available, so the pointer should stay nil")
+}
+
+func TestParse_Native_Errors(t *testing.T) {
+	type errConf struct {
+		Required string         `env:"NATIVE_ERR_REQUIRED,required"`
+		NotEmpty string         `env:"NATIVE_ERR_NOT_EMPTY,notEmpty"`
+		Int      int            `env:"NATIVE_ERR_INT"`
+		Option   string         `env:"NATIVE_ERR_OPTION,unknown"`
+		Map      map[string]int `env:"NATIVE_ERR_MAP"`
+		Chan     chan int       `env:"NATIVE_ERR_CHAN"`
+	}
+
+	t.Setenv("NATIVE_ERR_NOT_EMPTY", "")
+	t.Setenv("NATIVE_ERR_INT", "x")
+	t.Setenv("NATIVE_ERR_MAP", "a")
+	t.Setenv("NATIVE_ERR_CHAN", "x")
+
+	err := Parse(&errConf{})
+
+	ErrorIs(t, err, ErrNotSet)
+	ErrorIs(t, err, ErrEmpty)
+	ErrorIs(t, err, ErrUnsupportedOption)
+	ErrorIs(t, err, ErrUnsupportedType)
+
+	var fieldErr *FieldError
+
+	ErrorAs(t, err, &fieldErr)
+	Equal(t, "Required", fieldErr.Field)
+	Equal(t, "NATIVE_ERR_REQUIRED", fieldErr.Key)
+
+	Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 6) //nolint:errorlint // joined errors
+}
+
+func TestParse_NotStructPtr(t *testing.T) {
+	cf := conf{}
+
+	ErrorIs(t, Parse(cf), ErrNotStructPtr)
+	ErrorIs(t, Parse(nil), ErrNotStructPtr)
+}
+
+func TestParse_Unset(t *testing.T) {
+	type unsetConf struct {
+		Secret string `env:"NATIVE_UNSET,unset"`
+	}
+
+	t.Setenv("NATIVE_UNSET", "secret")
+
+	cf := unsetConf{}
+
+	NoError(t, Parse(&cf))
+	Equal(t, "secret", cf.Secret)
+
+	_, ok := os.LookupEnv("NATIVE_UNSET")
+	False(t, ok)
+}
+
+type profileConf struct {
+	LogFormat string `env:"PROFILE_LOG_FORMAT" envDefault:"console" envDefault.prod:"json"`
+	LogLevel  string `env:"PROFILE_LOG_LEVEL" envDefault:"info"`
+	Nested    struct {
+		Port string `env:"PORT" envDefault:"8080" envDefault.prod:"80"`
+	} `envPrefix:"PROFILE_"`
+}
+
+func TestParse_Profile(t *testing.T) {
+	tests := map[string]struct {
+		env       map[string]string
+		opts      []Option
+		wantFmt   string
+		wantLevel string
+		wantPort  string
+	}{
+		"default-profile": {
+			env:       map[string]string{"ENV": ""},
+			wantFmt:   "console",
+			wantLevel: "info",
+			wantPort:  "8080",
+		},
+		"env-selector": {
+			env:       map[string]string{"ENV": "prod"},
+			wantFmt:   "json",
+			wantLevel: "info",
+			wantPort:  "80",
+		},
+		"explicit-profile": {
+			env:       map[string]string{"ENV": "dev"},
+			opts:      []Option{WithProfile("prod")},
+			wantFmt:   "json",
+			wantLevel: "info",
+			wantPort:  "80",
+		},
+		"env-overrides-profile": {
+			env:       map[string]string{"ENV": "prod", "PROFILE_LOG_FORMAT": "text", "PROFILE_PORT": "9090"},
+			wantFmt:   "text",
+			wantLevel: "info",
+			wantPort:  "9090",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cf := profileConf{}
+
+			NoError(t, Parse(&cf, tt.opts...))
+			Equal(t, tt.wantFmt, cf.LogFormat)
+			Equal(t, tt.wantLevel, cf.LogLevel)
+			Equal(t, tt.wantPort, cf.Nested.Port)
+		})
+	}
+}
+
+type requiredIfConf struct {
+	TLS      bool   `env:"REQIF_TLS"`
+	CertFile string `env:"REQIF_CERT_FILE" envRequiredIf:"REQIF_TLS"`
+	Driver   string `env:"REQIF_DRIVER" envDefault:"sqlite"`
+	Password string `env:"REQIF_PASSWORD" envRequiredIf:"REQIF_DRIVER=postgres|mysql"`
+}
+
+func TestParse_RequiredIf(t *testing.T) {
+	tests := map[string]struct {
+		env     map[string]string
+		wantErr string
+	}{
+		"conditions-not-met": {
+			env: map[string]string{},
+		},
+		"bool-condition-met": {
+			env:     map[string]string{"REQIF_TLS": "on"},
+			wantErr: "REQIF_CERT_FILE",
+		},
+		"bool-condition-satisfied": {
+			env: map[string]string{"REQIF_TLS": "yes", "REQIF_CERT_FILE": "cert.pem"},
+		},
+		"value-condition-met": {
+			env:     map[string]string{"REQIF_DRIVER": "mysql"},
+			wantErr: "REQIF_PASSWORD",
+		},
+		"value-condition-satisfied": {
+			env: map[string]string{"REQIF_DRIVER": "postgres", "REQIF_PASSWORD": "secret"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"REQIF_TLS", "REQIF_CERT_FILE", "REQIF_DRIVER", "REQIF_PASSWORD"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			cf := requiredIfConf{}
+
+			err := Parse(&cf)
+
+			if tt.wantErr == "" {
+				NoError(t, err)
+
+				return
+			}
+
+			ErrorIs(t, err, ErrNotSet)
+			ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package envconf
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces values of secret fields in snapshots.
+const Redacted = "[REDACTED]"
+
+// Snapshot describes the effective configuration.
+type Snapshot struct {
+	Fields []FieldSnapshot `json:"fields"`
+
+	// Checksum identifies the configuration. Secret values are left out, so the checksum
+	// cannot be used to guess them, and instances differing only in secrets have the same checksum.
+	Checksum string `json:"checksum"`
+}
+
+// FieldSnapshot describes the effective value of a single field.
+type FieldSnapshot struct {
+	// Field is the path of the struct field, e.g. "HTTPD.Port".
+	Field string `json:"field"`
+
+	// Key is the environment variable bound to the field.
+	Key string `json:"key,omitempty"`
+
+	// Value is the formatted field value. Secret values are redacted.
+	Value string `json:"value"`
+
+	// Source is the origin of the value: "env" for the process environment,
+	// the name of the source (e.g. "dir:/etc/config"), "default" or "default.<profile>".
+	// It is empty if the field is not configured.
+	Source string `json:"source,omitempty"`
+
+	Secret bool `json:"secret,omitempty"`
+}
+
+// Difference describes a field that differs between two snapshots.
+// A or B is nil if the field is missing in the respective snapshot.
+type Difference struct {
+	Field string         `json:"field"`
+	A     *FieldSnapshot `json:"a"`
+	B     *FieldSnapshot `json:"b"`
+}
+
+// Provenance holds the sources of the values resolved by Parse, recorded with the WithProvenance option.
+type Provenance struct {
+	typ    reflect.Type
+	fields []resolved
+}
+
+// TakeSnapshot describes the configuration parsed into v, which has to be a pointer to a struct.
+// Values are taken from v, which may be a copy of the parsed struct, while the sources are the ones
+// recorded in p by Parse.
+func TakeSnapshot(v any, p *Provenance) (*Snapshot, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, ErrNotStructPtr
+	}
+
+	if p == nil || p.typ != rv.Elem().Type() {
+		return nil, ErrNotParsed
+	}
+
+	fields := p.fields
+
+	snapshot := &Snapshot{
+		Fields: make([]FieldSnapshot, 0, len(fields)),
+	}
+
+	hash := sha256.New()
+
+	for _, f := range fields {
+		value := formatValue(fieldByPath(rv.Elem(), f.path), f.tag)
+
+		if f.secret && value != "" {
+			value = Redacted
+		}
+
+		_, _ = fmt.Fprintf(hash, "%s\x00%s\x00%s\n", f.path, f.key, value)
+
+		snapshot.Fields = append(snapshot.Fields, FieldSnapshot{
+			Field:  f.path,
+			Key:    f.key,
+			Value:  value,
+			Source: f.origin,
+			Secret: f.secret,
+		})
+	}
+
+	snapshot.Checksum = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+
+	return snapshot, nil
+}
+
+// Diff compares two snapshots and returns fields with different values or sources, ordered by field.
+func Diff(a, b *Snapshot) []Difference {
+	index := func(s *Snapshot) map[string]*FieldSnapshot {
+		fields := make(map[string]*FieldSnapshot)
+
+		if s != nil {
+			for i := range s.Fields {
+				fields[s.Fields[i].Field] = &s.Fields[i]
+			}
+		}
+
+		return fields
+	}
+
+	fieldsA, fieldsB := index(a), index(b)
+
+	names := slices.Collect(maps.Keys(fieldsA))
+	for name := range fieldsB {
+		if _, ok := fieldsA[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	var diffs []Difference
+
+	for _, name := range names {
+		fa, fb := fieldsA[name], fieldsB[name]
+		if fa != nil && fb != nil && *fa == *fb {
+			continue
+		}
+
+		diffs = append(diffs, Difference{Field: name, A: fa, B: fb})
+	}
+
+	return diffs
+}
+
+// fieldByPath returns the struct field at the given path. Returns invalid value if
+// any of the pointers on the path is nil.
+func fieldByPath(v reflect.Value, path string) reflect.Value {
+	for name := range strings.SplitSeq(path, ".") {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.FieldByName(name)
+	}
+
+	return v
+}
+
+func formatValue(v reflect.Value, tag reflect.StructTag) string {
+	if !v.IsValid() {
+		return ""
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+
+		v = v.Elem()
+	}
+
+	// prefer pointer receivers, e.g. url.URL implements Stringer on a pointer
+	if !v.CanAddr() {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr.Elem()
+	}
+
+	switch i := v.Addr().Interface().(type) {
+	case encoding.TextMarshaler:
+		if text, err := i.MarshalText(); err == nil {
+			return string(text)
+		}
+	case fmt.Stringer:
+		return i.String()
+	}
+
+	switch v.Kind() { //nolint:exhaustive // other kinds are formatted by fmt
+	case reflect.Slice:
+		if v.IsNil() {
+			return ""
+		}
+
+		parts := make([]string, v.Len())
+
+		for i := range v.Len() {
+			parts[i] = formatValue(v.Index(i), tag)
+		}
+
+		return strings.Join(parts, separator(tag, tagSeparator, defaultSeparator))
+	case reflect.Map:
+		if v.IsNil() {
+			return ""
+		}
+
+		parts := make([]string, 0, v.Len())
+		kvSeparator := separator(tag, tagKeyValSeparator, defaultKeyValSeparator)
+
+		for it := v.MapRange(); it.Next(); {
+			parts = append(parts, formatValue(it.Key(), tag)+kvSeparator+formatValue(it.Value(), tag))
+		}
+
+		slices.Sort(parts)
+
+		return strings.Join(parts, separator(tag, tagSeparator, defaultSeparator))
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,156 @@
+package envconf
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/env"
+	. "github.com/stretchr/testify/require"
+)
+
+type snapshotConf struct {
+	Name     string            `env:"SNAP_NAME"`
+	Password string            `env:"SNAP_PASSWORD,secret"`
+	Format   string            `env:"SNAP_FORMAT" envDefault:"console" envDefault.prod:"json"`
+	Timeout  time.Duration     `env:"SNAP_TIMEOUT" envDefault:"5s"`
+	URL      url.URL           `env:"SNAP_URL"`
+	Hosts    []string          `env:"SNAP_HOSTS" envSeparator:";"`
+	Labels   map[string]string `env:"SNAP_LABELS"`
+	Token    string            `env:"TOKEN"`
+	DB       *struct {
+		Host string `env:"HOST"`
+		Port string `env:"PORT" envDefault:"5432"`
+	} `envPrefix:"SNAP_DB_"`
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	root := t.TempDir()
+
+	NoError(t, os.WriteFile(filepath.Join(root, "TOKEN"), []byte("token"), 0o600))
+
+	t.Setenv("SNAP_NAME", "name")
+	t.Setenv("SNAP_PASSWORD", "secret")
+	t.Setenv("SNAP_URL", "https://example.com")
+	t.Setenv("SNAP_HOSTS", "a;b")
+	t.Setenv("SNAP_LABELS", "b:2,a:1")
+
+	var provenance Provenance
+
+	opts := []Option{WithProfile("prod"), WithSource(env.NewDir(root))}
+	cf := snapshotConf{}
+
+	NoError(t, Parse(&cf, append(opts, WithProvenance(&provenance))...))
+
+	snapshot, err := TakeSnapshot(&cf, &provenance)
+	NoError(t, err)
+
+	Equal(t, []FieldSnapshot{
+		{Field: "Name", Key: "SNAP_NAME", Value: "name", Source: "env"},
+		{Field: "Password", Key: "SNAP_PASSWORD", Value: Redacted, Source: "env", Secret: true},
+		{Field: "Format", Key: "SNAP_FORMAT", Value: "json", Source: "default.prod"},
+		{Field: "Timeout", Key: "SNAP_TIMEOUT", Value: "5s", Source: "default"},
+		{Field: "URL", Key: "SNAP_URL", Value: "https://example.com", Source: "env"},
+		{Field: "Hosts", Key: "SNAP_HOSTS", Value: "a;b", Source: "env"},
+		{Field: "Labels", Key: "SNAP_LABELS", Value: "a:1,b:2", Source: "env"},
+		{Field: "Token", Key: "TOKEN", Value: "token", Source: "dir:" + root},
+		{Field: "DB.Host", Key: "SNAP_DB_HOST", Value: ""},
+		{Field: "DB.Port", Key: "SNAP_DB_PORT", Value: ""},
+	}, snapshot.Fields)
+	Nil(t, cf.DB)
+	NotEmpty(t, snapshot.Checksum)
+
+	t.Run("checksum-excludes-secrets", func(t *testing.T) {
+		t.Setenv("SNAP_PASSWORD", "other")
+
+		var otherProvenance Provenance
+
+		other := snapshotConf{}
+
+		NoError(t, Parse(&other, append(opts, WithProvenance(&otherProvenance))...))
+
+		otherSnapshot, err := TakeSnapshot(&other, &otherProvenance)
+		NoError(t, err)
+
+		Equal(t, snapshot.Checksum, otherSnapshot.Checksum)
+		Empty(t, Diff(snapshot, otherSnapshot))
+	})
+
+	t.Run("sources-recorded-by-parse", func(t *testing.T) {
+		t.Setenv("SNAP_NAME", "")
+		NoError(t, os.Remove(filepath.Join(root, "TOKEN")))
+
+		later, err := TakeSnapshot(&cf, &provenance)
+		NoError(t, err)
+
+		Equal(t, snapshot, later)
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		copied := cf
+
+		copiedSnapshot, err := TakeSnapshot(&copied, &provenance)
+		NoError(t, err)
+
+		Equal(t, snapshot, copiedSnapshot)
+	})
+
+	t.Run("not-parsed", func(t *testing.T) {
+		_, err := TakeSnapshot(&snapshotConf{}, &Provenance{})
+		ErrorIs(t, err, ErrNotParsed)
+
+		_, err = TakeSnapshot(&struct{}{}, &provenance)
+		ErrorIs(t, err, ErrNotParsed)
+	})
+
+	t.Run("not-struct-ptr", func(t *testing.T) {
+		_, err := TakeSnapshot(cf, &provenance)
+
+		ErrorIs(t, err, ErrNotStructPtr)
+	})
+}
+
+func TestTakeSnapshot_Unset(t *testing.T) {
+	t.Setenv("SNAP_UNSET", "value")
+
+	cf := struct {
+		Value string `env:"SNAP_UNSET,unset"`
+	}{}
+
+	var provenance Provenance
+
+	NoError(t, Parse(&cf, WithProvenance(&provenance)))
+
+	_, exists := os.LookupEnv("SNAP_UNSET")
+	False(t, exists)
+
+	snapshot, err := TakeSnapshot(&cf, &provenance)
+	NoError(t, err)
+
+	Equal(t, []FieldSnapshot{{Field: "Value", Key: "SNAP_UNSET", Value: "value", Source: "env"}}, snapshot.Fields)
+}
+
+func TestDiff(t *testing.T) {
+	a := &Snapshot{Fields: []FieldSnapshot{
+		{Field: "Name", Key: "NAME", Value: "a", Source: "env"},
+		{Field: "Port", Key: "PORT", Value: "80", Source: "default"},
+		{Field: "Removed", Key: "REMOVED", Value: "x", Source: "env"},
+	}}
+
+	b := &Snapshot{Fields: []FieldSnapshot{
+		{Field: "Added", Key: "ADDED", Value: "y", Source: "env"},
+		{Field: "Name", Key: "NAME", Value: "a", Source: "env"},
+		{Field: "Port", Key: "PORT", Value: "80", Source: "env"},
+	}}
+
+	Equal(t, []Difference{
+		{Field: "Added", B: &b.Fields[0]},
+		{Field: "Port", A: &a.Fields[1], B: &b.Fields[2]},
+		{Field: "Removed", A: &a.Fields[2]},
+	}, Diff(a, b))
+
+	Empty(t, Diff(a, a))
+	Len(t, Diff(a, nil), 3)
+}
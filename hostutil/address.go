@@ -0,0 +1,157 @@
+package hostutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const schemeSeparator = "://"
+
+// schemes maps supported schemes to networks and default ports.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var schemes = map[string]struct {
+	network     string
+	defaultPort string
+}{
+	"tcp":        {network: "tcp"},
+	"tcp4":       {network: "tcp4"},
+	"tcp6":       {network: "tcp6"},
+	"unix":       {network: "unix"},
+	"unixpacket": {network: "unixpacket"},
+	"http":       {network: "tcp", defaultPort: "80"},
+	"https":      {network: "tcp", defaultPort: "443"},
+}
+
+// Address is a network address with a scheme. Supported formats are:
+//   - tcp://host:port, tcp4://host:port, tcp6://host:port
+//   - unix:///path/to/socket, unixpacket:///path/to/socket
+//   - http://host[:port], https://host[:port]
+//   - host:port, which is a shorthand for tcp://host:port
+//
+// Schemes http and https are TCP addresses with default ports 80 and 443.
+// Host and port are validated the same way ParseHostPort does it.
+//
+// Address implements encoding.TextMarshaler and encoding.TextUnmarshaler,
+// so it can be used in JSON documents and envconf structs.
+type Address struct {
+	Scheme string
+	Host   string
+	Port   string
+
+	// Path is the socket path for unix and unixpacket schemes.
+	Path string
+}
+
+// ParseAddress parses the address. It returns an AddressError if the address is not valid.
+func ParseAddress(s string) (Address, error) {
+	scheme, rest, found := strings.Cut(s, schemeSeparator)
+	if !found {
+		scheme, rest = "tcp", s
+	}
+
+	spec, ok := schemes[scheme]
+	if !ok {
+		return Address{}, &AddressError{Address: s, Err: fmt.Errorf("%w: %q", ErrUnsupportedScheme, scheme)}
+	}
+
+	if strings.HasPrefix(spec.network, "unix") {
+		if rest == "" {
+			return Address{}, &AddressError{Address: s, Err: ErrMissingPath}
+		}
+
+		return Address{Scheme: scheme, Path: rest}, nil
+	}
+
+	_, port, err := splitAddress(rest)
+	if err != nil {
+		return Address{}, &AddressError{Address: s, Err: err}
+	}
+
+	if port == "" {
+		port = spec.defaultPort
+	}
+
+	if port == "" {
+		return Address{}, &AddressError{Address: s, Err: ErrMissingPort}
+	}
+
+	hp, err := ParseHostPort(rest, "", port)
+	if err != nil {
+		var addrErr *AddressError
+		if errors.As(err, &addrErr) {
+			err = addrErr.Err
+		}
+
+		return Address{}, &AddressError{Address: s, Err: err}
+	}
+
+	return Address{Scheme: scheme, Host: hp.Host, Port: hp.Port}, nil
+}
+
+// Network returns the network name, as expected by net.Dial and net.Listen.
+func (a Address) Network() string {
+	return schemes[a.Scheme].network
+}
+
+// Addr returns the address, as expected by net.Dial and net.Listen.
+// It is either host:port, or the socket path.
+func (a Address) Addr() string {
+	if a.Path != "" {
+		return a.Path
+	}
+
+	return net.JoinHostPort(a.Host, a.Port)
+}
+
+// String implements Stringer interface. The result can be parsed with ParseAddress.
+func (a Address) String() string {
+	if a.Scheme == "" {
+		return ""
+	}
+
+	return a.Scheme + schemeSeparator + a.Addr()
+}
+
+// Dial connects to the address.
+func (a Address) Dial(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+
+	//nolint:wrapcheck // return the error as is
+	return d.DialContext(ctx, a.Network(), a.Addr())
+}
+
+// Listen announces on the address.
+func (a Address) Listen(ctx context.Context) (net.Listener, error) {
+	var lc net.ListenConfig
+
+	//nolint:wrapcheck // return the error as is
+	return lc.Listen(ctx, a.Network(), a.Addr())
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// Empty text is converted to zero Address.
+func (a *Address) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*a = Address{}
+
+		return nil
+	}
+
+	parsed, err := ParseAddress(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+
+	return nil
+}
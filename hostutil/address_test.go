@@ -0,0 +1,186 @@
+package hostutil
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input       string
+		want        Address
+		wantNetwork string
+		wantAddr    string
+		wantString  string
+	}{
+		"tcp": {
+			input:       "tcp://example.com:8080",
+			want:        Address{Scheme: "tcp", Host: "example.com", Port: "8080"},
+			wantNetwork: "tcp",
+			wantAddr:    "example.com:8080",
+			wantString:  "tcp://example.com:8080",
+		},
+		"tcp6": {
+			input:       "tcp6://[::1]:8080",
+			want:        Address{Scheme: "tcp6", Host: "::1", Port: "8080"},
+			wantNetwork: "tcp6",
+			wantAddr:    "[::1]:8080",
+			wantString:  "tcp6://[::1]:8080",
+		},
+		"no-scheme": {
+			input:       "127.0.0.1:http",
+			want:        Address{Scheme: "tcp", Host: "127.0.0.1", Port: "80"},
+			wantNetwork: "tcp",
+			wantAddr:    "127.0.0.1:80",
+			wantString:  "tcp://127.0.0.1:80",
+		},
+		"https-default-port": {
+			input:       "https://example.com",
+			want:        Address{Scheme: "https", Host: "example.com", Port: "443"},
+			wantNetwork: "tcp",
+			wantAddr:    "example.com:443",
+			wantString:  "https://example.com:443",
+		},
+		"http-port": {
+			input:       "http://example.com:8080",
+			want:        Address{Scheme: "http", Host: "example.com", Port: "8080"},
+			wantNetwork: "tcp",
+			wantAddr:    "example.com:8080",
+			wantString:  "http://example.com:8080",
+		},
+		"unix": {
+			input:       "unix:///run/app.sock",
+			want:        Address{Scheme: "unix", Path: "/run/app.sock"},
+			wantNetwork: "unix",
+			wantAddr:    "/run/app.sock",
+			wantString:  "unix:///run/app.sock",
+		},
+		"unixpacket-relative": {
+			input:       "unixpacket://app.sock",
+			want:        Address{Scheme: "unixpacket", Path: "app.sock"},
+			wantNetwork: "unixpacket",
+			wantAddr:    "app.sock",
+			wantString:  "unixpacket://app.sock",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseAddress(tt.input)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantNetwork, got.Network())
+			require.Equal(t, tt.wantAddr, got.Addr())
+			require.Equal(t, tt.wantString, got.String())
+
+			roundTrip, err := ParseAddress(got.String())
+
+			require.NoError(t, err)
+			require.Equal(t, got, roundTrip)
+		})
+	}
+}
+
+func TestParseAddress_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input   string
+		wantErr error
+	}{
+		"unsupported-scheme": {input: "udp://host:53", wantErr: ErrUnsupportedScheme},
+		"missing-port":       {input: "tcp://host", wantErr: ErrMissingPort},
+		"missing-port-plain": {input: "host", wantErr: ErrMissingPort},
+		"missing-path":       {input: "unix://", wantErr: ErrMissingPath},
+		"invalid-port":       {input: "tcp://host:99999", wantErr: ErrPortRange},
+		"invalid-host":       {input: "https://bad_host", wantErr: ErrInvalidHost},
+		"invalid-ipv6":       {input: "tcp://[::1:80", wantErr: ErrMissingBracket},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseAddress(tt.input)
+
+			require.ErrorIs(t, err, tt.wantErr)
+
+			var addrErr *AddressError
+
+			require.ErrorAs(t, err, &addrErr)
+			require.Equal(t, tt.input, addrErr.Address)
+		})
+	}
+}
+
+func TestAddress_JSON(t *testing.T) {
+	t.Parallel()
+
+	type upstreams struct {
+		Primary  Address  `json:"primary"`
+		Fallback *Address `json:"fallback"`
+		Empty    Address  `json:"empty"`
+	}
+
+	input := `{"primary":"https://example.com:443","fallback":"unix:///run/app.sock","empty":""}`
+
+	var got upstreams
+
+	require.NoError(t, json.Unmarshal([]byte(input), &got))
+	require.Equal(t, Address{Scheme: "https", Host: "example.com", Port: "443"}, got.Primary)
+	require.Equal(t, Address{Scheme: "unix", Path: "/run/app.sock"}, *got.Fallback)
+	require.Equal(t, Address{}, got.Empty)
+
+	output, err := json.Marshal(got)
+
+	require.NoError(t, err)
+	require.JSONEq(t, input, string(output))
+
+	require.Error(t, json.Unmarshal([]byte(`{"primary":"udp://host:1"}`), &got))
+}
+
+func TestAddress_ListenDial(t *testing.T) {
+	t.Parallel()
+
+	for name, input := range map[string]string{
+		"tcp":  "tcp://127.0.0.1:0",
+		"unix": "unix://" + filepath.Join(t.TempDir(), "app.sock"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			addr, err := ParseAddress(input)
+			require.NoError(t, err)
+
+			ln, err := addr.Listen(t.Context())
+			require.NoError(t, err)
+
+			defer func() { _ = ln.Close() }()
+
+			go func() {
+				if conn, err := ln.Accept(); err == nil {
+					_ = conn.Close()
+				}
+			}()
+
+			// ephemeral port has to be resolved from the listener
+			if tcpAddr, ok := ln.Addr().(*net.TCPAddr); ok {
+				addr, err = ParseAddress(tcpAddr.String())
+				require.NoError(t, err)
+			}
+
+			conn, err := addr.Dial(t.Context())
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package hostutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Strategy defines the order in which the Dialer tries the hosts.
+type Strategy int
+
+const (
+	// Failover tries the hosts in the order they were specified.
+	Failover Strategy = iota
+
+	// RoundRobin starts with the next host on each dial.
+	RoundRobin
+
+	// Random tries the hosts in random order.
+	Random
+
+	// LeastRecentlyFailed tries the hosts that never failed first,
+	// followed by the hosts ordered by the time of their last failure.
+	LeastRecentlyFailed
+)
+
+const (
+	defaultAttemptTimeout = 5 * time.Second
+	defaultMinBackoff     = time.Second
+	defaultMaxBackoff     = time.Minute
+)
+
+// Dialer dials one of the hosts from a list, using the configured strategy.
+//
+// Hosts failing to connect are passively marked unhealthy, and are skipped
+// for the back-off period, which doubles with each consecutive failure.
+// If all hosts are unhealthy, they are tried anyway, soonest to recover first.
+//
+// Dialer.DialContext can be used as http.Transport.DialContext.
+type Dialer struct {
+	hosts          []*dialHost
+	strategy       Strategy
+	attemptTimeout time.Duration
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+
+	dial func(ctx context.Context, network, address string) (net.Conn, error)
+	now  func() time.Time
+
+	mu   sync.Mutex
+	next int
+}
+
+// dialHost tracks the health of a single host.
+type dialHost struct {
+	address     string
+	failures    int
+	lastFailure time.Time
+	retryAt     time.Time
+}
+
+// DialerOption configures a Dialer.
+type DialerOption func(d *Dialer)
+
+// WithStrategy sets the strategy. Defaults to Failover.
+func WithStrategy(strategy Strategy) DialerOption {
+	return func(d *Dialer) {
+		d.strategy = strategy
+	}
+}
+
+// WithAttemptTimeout sets the timeout of a single connection attempt. Defaults to 5 seconds.
+func WithAttemptTimeout(timeout time.Duration) DialerOption {
+	return func(d *Dialer) {
+		d.attemptTimeout = timeout
+	}
+}
+
+// WithBackoff sets the back-off period of failed hosts. The period starts at minBackoff
+// and doubles with each consecutive failure, up to maxBackoff. Defaults to 1 second and 1 minute.
+func WithBackoff(minBackoff, maxBackoff time.Duration) DialerOption {
+	return func(d *Dialer) {
+		d.minBackoff = minBackoff
+		d.maxBackoff = maxBackoff
+	}
+}
+
+// NewDialer creates a new Dialer for the hosts.
+func NewDialer(hosts []HostPort, opts ...DialerOption) *Dialer {
+	var nd net.Dialer
+
+	d := &Dialer{
+		hosts:          make([]*dialHost, len(hosts)),
+		strategy:       Failover,
+		attemptTimeout: defaultAttemptTimeout,
+		minBackoff:     defaultMinBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		dial:           nd.DialContext,
+		now:            time.Now,
+	}
+
+	for i, hp := range hosts {
+		d.hosts[i] = &dialHost{address: hp.String()}
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// DialContext connects to one of the hosts. The address argument is ignored,
+// which allows the dialer to be used as http.Transport.DialContext.
+// Network defaults to "tcp". It returns errors of all attempts if no host could be reached.
+func (d *Dialer) DialContext(ctx context.Context, network, _ string) (net.Conn, error) {
+	if network == "" {
+		network = "tcp"
+	}
+
+	candidates := d.candidates()
+	if len(candidates) == 0 {
+		return nil, ErrNoHosts
+	}
+
+	errs := make([]error, 0, len(candidates))
+
+	for _, host := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, err //nolint:wrapcheck // return the context error as is
+		}
+
+		conn, err := d.attempt(ctx, network, host.address)
+		if err == nil {
+			d.markHealthy(host)
+
+			return conn, nil
+		}
+
+		// the host is not to blame if the caller gave up
+		if ctx.Err() != nil {
+			return nil, ctx.Err() //nolint:wrapcheck // return the context error as is
+		}
+
+		d.markFailed(host)
+
+		errs = append(errs, fmt.Errorf("dial %s: %w", host.address, err))
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func (d *Dialer) attempt(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, d.attemptTimeout)
+		defer cancel()
+	}
+
+	return d.dial(ctx, network, address)
+}
+
+// candidates returns the hosts in the order they should be tried.
+// Healthy hosts are ordered by the strategy, followed by unhealthy hosts ordered by their recovery time.
+func (d *Dialer) candidates() []*dialHost {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hosts := slices.Clone(d.hosts)
+
+	switch d.strategy {
+	case Failover:
+	case RoundRobin:
+		if len(hosts) > 0 {
+			start := d.next % len(hosts)
+			d.next++
+
+			hosts = slices.Concat(hosts[start:], hosts[:start])
+		}
+	case Random:
+		rand.Shuffle(len(hosts), func(i, j int) {
+			hosts[i], hosts[j] = hosts[j], hosts[i]
+		})
+	case LeastRecentlyFailed:
+		slices.SortStableFunc(hosts, func(a, b *dialHost) int {
+			return a.lastFailure.Compare(b.lastFailure)
+		})
+	}
+
+	now := d.now()
+
+	healthy := slices.DeleteFunc(slices.Clone(hosts), func(h *dialHost) bool {
+		return h.retryAt.After(now)
+	})
+
+	unhealthy := slices.DeleteFunc(hosts, func(h *dialHost) bool {
+		return !h.retryAt.After(now)
+	})
+
+	slices.SortStableFunc(unhealthy, func(a, b *dialHost) int {
+		return a.retryAt.Compare(b.retryAt)
+	})
+
+	return append(healthy, unhealthy...)
+}
+
+func (d *Dialer) markHealthy(host *dialHost) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	host.failures = 0
+	host.retryAt = time.Time{}
+}
+
+func (d *Dialer) markFailed(host *dialHost) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// double the back-off for each consecutive failure
+	backoff := d.minBackoff
+	for range host.failures {
+		if backoff >= d.maxBackoff {
+			break
+		}
+
+		backoff *= 2
+	}
+
+	backoff = min(backoff, d.maxBackoff)
+
+	host.failures++
+	host.lastFailure = d.now()
+	host.retryAt = host.lastFailure.Add(backoff)
+}
@@ -0,0 +1,235 @@
+package hostutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDialer records dialed addresses and fails for the addresses marked as down.
+type recordingDialer struct {
+	mu     sync.Mutex
+	down   map[string]bool
+	dialed []string
+}
+
+func (r *recordingDialer) dial(_ context.Context, _, address string) (net.Conn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dialed = append(r.dialed, address)
+
+	if r.down[address] {
+		return nil, errors.New("connection refused")
+	}
+
+	client, server := net.Pipe()
+
+	_ = server.Close()
+
+	return client, nil
+}
+
+func (r *recordingDialer) reset() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dialed := r.dialed
+	r.dialed = nil
+
+	return dialed
+}
+
+func newTestDialer(strategy Strategy, down ...string) (*Dialer, *recordingDialer, *time.Time) {
+	rec := &recordingDialer{down: map[string]bool{}}
+	for _, addr := range down {
+		rec.down[addr] = true
+	}
+
+	now := time.Unix(0, 0)
+
+	d := NewDialer([]HostPort{{"a", "1"}, {"b", "1"}, {"c", "1"}}, WithStrategy(strategy), WithBackoff(time.Second, 4*time.Second))
+	d.dial = rec.dial
+	d.now = func() time.Time { return now }
+
+	return d, rec, &now
+}
+
+func TestDialer_Failover(t *testing.T) {
+	t.Parallel()
+
+	d, rec, now := newTestDialer(Failover, "a:1")
+
+	_, err := d.DialContext(t.Context(), "tcp", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a:1", "b:1"}, rec.reset())
+
+	// a is backing off
+	_, err = d.DialContext(t.Context(), "tcp", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b:1"}, rec.reset())
+
+	// a is retried once the back-off expires
+	*now = now.Add(time.Second)
+
+	_, err = d.DialContext(t.Context(), "tcp", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a:1", "b:1"}, rec.reset())
+
+	// the back-off doubles
+	*now = now.Add(time.Second)
+
+	_, err = d.DialContext(t.Context(), "tcp", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b:1"}, rec.reset())
+}
+
+func TestDialer_RoundRobin(t *testing.T) {
+	t.Parallel()
+
+	d, rec, _ := newTestDialer(RoundRobin)
+
+	for range 4 {
+		_, err := d.DialContext(t.Context(), "", "")
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []string{"a:1", "b:1", "c:1", "a:1"}, rec.reset())
+}
+
+func TestDialer_Random(t *testing.T) {
+	t.Parallel()
+
+	d, rec, _ := newTestDialer(Random, "a:1", "b:1", "c:1")
+
+	_, err := d.DialContext(t.Context(), "tcp", "")
+	require.Error(t, err)
+	require.ElementsMatch(t, []string{"a:1", "b:1", "c:1"}, rec.reset())
+}
+
+func TestDialer_LeastRecentlyFailed(t *testing.T) {
+	t.Parallel()
+
+	d, rec, now := newTestDialer(LeastRecentlyFailed, "a:1", "b:1")
+
+	_, err := d.DialContext(t.Context(), "tcp", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a:1", "b:1", "c:1"}, rec.reset())
+
+	// all recovered, c never failed, a failed before b
+	*now = now.Add(time.Minute)
+	rec.down = map[string]bool{"c:1": true}
+
+	_, err = d.DialContext(t.Context(), "tcp", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"c:1", "a:1"}, rec.reset())
+}
+
+func TestDialer_AllUnhealthy(t *testing.T) {
+	t.Parallel()
+
+	d, rec, now := newTestDialer(Failover, "a:1", "b:1", "c:1")
+
+	_, err := d.DialContext(t.Context(), "tcp", "")
+	require.ErrorContains(t, err, "dial a:1")
+	require.ErrorContains(t, err, "dial c:1")
+	require.Equal(t, []string{"a:1", "b:1", "c:1"}, rec.reset())
+
+	// all hosts are backing off, the ones recovering sooner are tried first
+	d.hosts[0].retryAt = now.Add(3 * time.Second)
+	d.hosts[1].retryAt = now.Add(2 * time.Second)
+	d.hosts[2].retryAt = now.Add(time.Second)
+
+	rec.down = map[string]bool{}
+
+	_, err = d.DialContext(t.Context(), "tcp", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"c:1"}, rec.reset())
+}
+
+func TestDialer_ContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	d, _, _ := newTestDialer(Failover)
+
+	d.dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := d.DialContext(ctx, "tcp", "")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the host is not marked as failed
+	require.Zero(t, d.hosts[0].failures)
+}
+
+func TestDialer_AttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	d, rec, _ := newTestDialer(Failover)
+
+	d.attemptTimeout = 10 * time.Millisecond
+	d.dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if address == "a:1" {
+			<-ctx.Done()
+
+			return nil, ctx.Err()
+		}
+
+		return rec.dial(ctx, network, address)
+	}
+
+	_, err := d.DialContext(t.Context(), "tcp", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, d.hosts[0].failures)
+}
+
+func TestDialer_NoHosts(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewDialer(nil).DialContext(t.Context(), "tcp", "")
+	require.ErrorIs(t, err, ErrNoHosts)
+}
+
+func TestDialer_HTTPTransport(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	// bind and release a port, so nothing listens on it
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, ln.Close())
+
+	hosts, err := ParseHostPortList(ln.Addr().String()+","+srv.Listener.Addr().String(), "", "80")
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: &http.Transport{DialContext: NewDialer(hosts).DialContext},
+	}
+
+	rsp, err := client.Get("http://upstream/")
+	require.NoError(t, err)
+
+	defer func() { _ = rsp.Body.Close() }()
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(body))
+}
@@ -0,0 +1,75 @@
+package hostutil
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMissingDefaultPort is returned when the default port is not specified.
+	ErrMissingDefaultPort = errors.New("missing default port")
+
+	// ErrMissingBracket is returned when the IPv6 literal is missing the closing bracket.
+	ErrMissingBracket = errors.New("missing ']' in address")
+
+	// ErrUnexpectedBracket is returned when a bracket is found outside of the IPv6 literal.
+	ErrUnexpectedBracket = errors.New("unexpected bracket in address")
+
+	// ErrUnexpectedCharacters is returned when the IPv6 literal is followed by anything but the port.
+	ErrUnexpectedCharacters = errors.New("unexpected characters after ']'")
+
+	// ErrTooManyColons is returned when the address contains multiple colons, but it is not an IPv6 literal.
+	ErrTooManyColons = errors.New("too many colons in address")
+
+	// ErrInvalidIPv6 is returned when the bracketed host is not a valid IPv6 address.
+	ErrInvalidIPv6 = errors.New("invalid IPv6 address")
+
+	// ErrWhitespace is returned when the address or port contains whitespace.
+	ErrWhitespace = errors.New("whitespace in address")
+
+	// ErrInvalidHost is returned when the host is neither an IP address nor a valid RFC 1123 hostname.
+	ErrInvalidHost = errors.New("invalid host")
+
+	// ErrInvalidPort is returned when the port is neither a number nor a known service name.
+	ErrInvalidPort = errors.New("invalid port")
+
+	// ErrPortRange is returned when the port is out of the 0-65535 range.
+	ErrPortRange = errors.New("port out of range")
+
+	// ErrUnsupportedScheme is returned when the address scheme is not supported.
+	ErrUnsupportedScheme = errors.New("unsupported scheme")
+
+	// ErrMissingPort is returned when the address has no port and its scheme has no default port.
+	ErrMissingPort = errors.New("missing port")
+
+	// ErrMissingPath is returned when the unix socket address has no path.
+	ErrMissingPath = errors.New("missing socket path")
+
+	// ErrInvalidAttribute is returned when the weight or priority of an address is not a non-negative number.
+	ErrInvalidAttribute = errors.New("invalid address attribute")
+
+	// ErrNoHosts is returned when the dialer has no hosts to dial.
+	ErrNoHosts = errors.New("no hosts to dial")
+
+	// ErrNoInterfaceAddresses is returned when a bind specification matches no interface addresses.
+	ErrNoInterfaceAddresses = errors.New("no matching interface addresses")
+
+	// ErrUnsupportedNetwork is returned when the network is neither TCP nor UDP.
+	ErrUnsupportedNetwork = errors.New("unsupported network")
+)
+
+// AddressError describes an address that cannot be parsed.
+type AddressError struct {
+	Address string
+	Err     error
+}
+
+// Error implements error interface.
+func (e *AddressError) Error() string {
+	return fmt.Sprintf("address %q: %v", e.Address, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *AddressError) Unwrap() error {
+	return e.Err
+}
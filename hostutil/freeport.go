@@ -0,0 +1,95 @@
+package hostutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// FreePort returns a port which is free on the host for the network ("tcp", "tcp4", "tcp6", "udp", "udp4", "udp6").
+// The port is released before returning, so another process may take it in the meantime.
+// Prefer ListenFree and ListenPacketFree, which return already bound sockets.
+func FreePort(network, host string) (int, error) {
+	ports, err := FreePorts(network, host, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return ports[0], nil
+}
+
+// FreePorts returns n distinct ports which are free on the host for the network.
+// All ports are held until the last one is picked, so the result contains no duplicates.
+func FreePorts(network, host string, n int) ([]int, error) {
+	ports := make([]int, 0, n)
+	closers := make([]func() error, 0, n)
+
+	defer func() {
+		for _, closeFn := range closers {
+			_ = closeFn()
+		}
+	}()
+
+	for range n {
+		port, closeFn, err := bindFree(context.Background(), network, host)
+		if err != nil {
+			return nil, err
+		}
+
+		ports = append(ports, port)
+		closers = append(closers, closeFn)
+	}
+
+	return ports, nil
+}
+
+// ListenFree returns a TCP listener bound to a free port on the host.
+func ListenFree(ctx context.Context, network, host string) (net.Listener, error) {
+	if !strings.HasPrefix(network, "tcp") {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, network)
+	}
+
+	var lc net.ListenConfig
+
+	ln, err := lc.Listen(ctx, network, net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	return ln, nil
+}
+
+// ListenPacketFree returns a UDP connection bound to a free port on the host.
+func ListenPacketFree(ctx context.Context, network, host string) (net.PacketConn, error) {
+	if !strings.HasPrefix(network, "udp") {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, network)
+	}
+
+	var lc net.ListenConfig
+
+	conn, err := lc.ListenPacket(ctx, network, net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	return conn, nil
+}
+
+func bindFree(ctx context.Context, network, host string) (int, func() error, error) {
+	if strings.HasPrefix(network, "udp") {
+		conn, err := ListenPacketFree(ctx, network, host)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		return conn.LocalAddr().(*net.UDPAddr).Port, conn.Close, nil //nolint:forcetypeassert // UDP network
+	}
+
+	ln, err := ListenFree(ctx, network, host)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return ln.Addr().(*net.TCPAddr).Port, ln.Close, nil //nolint:forcetypeassert // TCP network
+}
@@ -0,0 +1,68 @@
+package hostutil
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreePort(t *testing.T) {
+	t.Parallel()
+
+	for _, network := range []string{"tcp", "udp"} {
+		port, err := FreePort(network, "127.0.0.1")
+
+		require.NoError(t, err)
+		require.Positive(t, port)
+	}
+
+	_, err := FreePort("ip", "127.0.0.1")
+	require.ErrorIs(t, err, ErrUnsupportedNetwork)
+}
+
+func TestFreePorts(t *testing.T) {
+	t.Parallel()
+
+	ports, err := FreePorts("tcp4", "127.0.0.1", 5)
+
+	require.NoError(t, err)
+	require.Len(t, ports, 5)
+
+	seen := map[int]bool{}
+
+	for _, port := range ports {
+		require.False(t, seen[port])
+
+		seen[port] = true
+	}
+
+	// ports are released
+	ln, err := net.Listen("tcp4", net.JoinHostPort("127.0.0.1", strconv.Itoa(ports[0])))
+
+	require.NoError(t, err)
+	require.NoError(t, ln.Close())
+}
+
+func TestListenFree(t *testing.T) {
+	t.Parallel()
+
+	ln, err := ListenFree(t.Context(), "tcp", "127.0.0.1")
+
+	require.NoError(t, err)
+	require.NotZero(t, ln.Addr().(*net.TCPAddr).Port)
+	require.NoError(t, ln.Close())
+
+	conn, err := ListenPacketFree(t.Context(), "udp", "127.0.0.1")
+
+	require.NoError(t, err)
+	require.NotZero(t, conn.LocalAddr().(*net.UDPAddr).Port)
+	require.NoError(t, conn.Close())
+
+	_, err = ListenFree(t.Context(), "udp", "127.0.0.1")
+	require.ErrorIs(t, err, ErrUnsupportedNetwork)
+
+	_, err = ListenPacketFree(t.Context(), "tcp", "127.0.0.1")
+	require.ErrorIs(t, err, ErrUnsupportedNetwork)
+}
@@ -1,8 +1,18 @@
 package hostutil
 
 import (
+	"errors"
+	"fmt"
 	"net"
+	"net/netip"
+	"strconv"
 	"strings"
+	"unicode"
+)
+
+const (
+	maxHostnameLength = 253
+	maxLabelLength    = 63
 )
 
 // HostPort encapsulates host and port.
@@ -17,39 +27,219 @@ type HostPort struct {
 //   - host:port
 //   - :port
 //   - host:
+//   - [ipv6]
+//   - [ipv6]:port
+//   - [ipv6]:
+//   - ipv6
+//
+// IPv6 addresses may contain a zone identifier, e.g. fe80::1%eth0.
+// A bare IPv6 address never contains a port, brackets have to be used to specify one.
 //
 // The method inserts specified defaultPort if port is omitted in address provided.
 // The method panics if defaultPort is not specified.
+// Host and port are not validated, and an address which cannot be parsed is split at the first colon,
+// e.g. "server:1:2" has host "server" and port "1:2". Use ParseHostPort to validate them and to handle errors.
 // If address is empty, method will return address in form of ":defaultPort".
 func NewHostPort(address, port, defaultPort string) HostPort {
+	hp, err := lenientHostPort(address, port, defaultPort)
+	if err != nil {
+		panic(err)
+	}
+
+	return hp
+}
+
+// ParseHostPort composes HostPort the same way NewHostPort does, and validates it.
+// It returns an AddressError instead of panicking. Validation rules are:
+//   - address and ports must not contain whitespace
+//   - host must be empty, an IP address or a hostname valid per RFC 1123
+//   - port must be in range 1-65535, or 0 for an ephemeral port
+//   - named service ports (e.g. http, https) are resolved to numbers using
+//     the system services database (/etc/services)
+func ParseHostPort(address, port, defaultPort string) (HostPort, error) {
+	if strings.ContainsFunc(port, unicode.IsSpace) || strings.ContainsFunc(defaultPort, unicode.IsSpace) ||
+		strings.ContainsFunc(strings.TrimSpace(address), unicode.IsSpace) {
+		return HostPort{}, &AddressError{Address: address, Err: ErrWhitespace}
+	}
+
+	hp, err := composeHostPort(address, port, defaultPort)
+	if err != nil {
+		return HostPort{}, err
+	}
+
+	if err := validateHost(hp.Host); err != nil {
+		return HostPort{}, &AddressError{Address: address, Err: err}
+	}
+
+	if hp.Port, err = resolvePort(hp.Port); err != nil {
+		return HostPort{}, &AddressError{Address: address, Err: err}
+	}
+
+	return hp, nil
+}
+
+// composeHostPort composes HostPort without validating host and port.
+func composeHostPort(address, port, defaultPort string) (HostPort, error) {
 	if defaultPort == "" {
-		panic("missing default port")
+		return HostPort{}, &AddressError{Address: address, Err: ErrMissingDefaultPort}
 	}
 
 	if port == "" {
 		port = defaultPort
 	}
 
-	address = strings.TrimSpace(address)
+	host, addressPort, err := splitAddress(strings.TrimSpace(address))
+	if err != nil {
+		return HostPort{}, &AddressError{Address: address, Err: err}
+	}
 
-	if address == "" {
-		return HostPort{Port: port}
+	if addressPort == "" {
+		addressPort = port
 	}
 
-	colonAt := strings.Index(address, ":")
+	return HostPort{Host: host, Port: addressPort}, nil
+}
 
-	if colonAt == -1 {
-		return HostPort{Host: address, Port: port}
+// lenientHostPort composes HostPort the way NewHostPort does. It fails only if defaultPort is not specified.
+func lenientHostPort(address, port, defaultPort string) (HostPort, error) {
+	hp, err := composeHostPort(address, port, defaultPort)
+	if err == nil || errors.Is(err, ErrMissingDefaultPort) {
+		return hp, err
 	}
 
-	if colonAt+1 == len(address) {
-		return HostPort{Host: address[0:colonAt], Port: port}
+	if port == "" {
+		port = defaultPort
 	}
 
-	return HostPort{Host: address[0:colonAt], Port: address[colonAt+1:]}
+	host, addressPort, _ := strings.Cut(strings.TrimSpace(address), ":")
+	if addressPort == "" {
+		addressPort = port
+	}
+
+	return HostPort{Host: host, Port: addressPort}, nil
 }
 
 // String implements Stringer interface.
 func (h HostPort) String() string {
 	return net.JoinHostPort(h.Host, h.Port)
 }
+
+// splitAddress splits address into host and port. Port is empty if not specified.
+func splitAddress(address string) (string, string, error) {
+	if address == "" {
+		return "", "", nil
+	}
+
+	if address[0] == '[' {
+		end := strings.IndexByte(address, ']')
+		if end == -1 {
+			return "", "", ErrMissingBracket
+		}
+
+		host := address[1:end]
+
+		if addr, err := netip.ParseAddr(host); err != nil || !addr.Is6() {
+			return "", "", ErrInvalidIPv6
+		}
+
+		rest := address[end+1:]
+
+		switch {
+		case rest == "":
+			return host, "", nil
+		case rest[0] == ':' && !strings.ContainsAny(rest, "[]"):
+			return host, rest[1:], nil
+		default:
+			return "", "", ErrUnexpectedCharacters
+		}
+	}
+
+	if strings.ContainsAny(address, "[]") {
+		return "", "", ErrUnexpectedBracket
+	}
+
+	switch strings.Count(address, ":") {
+	case 0:
+		return address, "", nil
+	case 1:
+		host, port, _ := strings.Cut(address, ":")
+
+		return host, port, nil
+	default:
+		if addr, err := netip.ParseAddr(address); err == nil && addr.Is6() {
+			return address, "", nil
+		}
+
+		return "", "", ErrTooManyColons
+	}
+}
+
+// validateHost checks the host is empty, an IP address or a valid RFC 1123 hostname.
+func validateHost(host string) error {
+	if host == "" {
+		return nil
+	}
+
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil
+	}
+
+	if !isValidHostname(host) {
+		return fmt.Errorf("%w: %q", ErrInvalidHost, host)
+	}
+
+	return nil
+}
+
+// isValidHostname checks hostname syntax per RFC 1123. A trailing dot is allowed.
+func isValidHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+
+	if host == "" || len(host) > maxHostnameLength {
+		return false
+	}
+
+	labels := strings.Split(host, ".")
+
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > maxLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, c := range label {
+			if !isAlphanumeric(c) && c != '-' {
+				return false
+			}
+		}
+	}
+
+	// the top-level label is never all-numeric, so invalid IPv4 addresses are not taken for hostnames
+	return strings.ContainsFunc(labels[len(labels)-1], func(c rune) bool {
+		return c < '0' || c > '9'
+	})
+}
+
+func isAlphanumeric(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// resolvePort validates the port number, or resolves the service name to a port number.
+func resolvePort(port string) (string, error) {
+	const maxPort = 65535
+
+	if port != "" && strings.Trim(port, "0123456789") == "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n > maxPort {
+			return "", fmt.Errorf("%w: %s", ErrPortRange, port)
+		}
+
+		return strconv.Itoa(n), nil
+	}
+
+	n, err := net.LookupPort("tcp", port)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPort, port)
+	}
+
+	return strconv.Itoa(n), nil
+}
@@ -2,6 +2,7 @@ package hostutil
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -23,6 +24,14 @@ func TestNewHostPort(t *testing.T) {
 		{"server:1234", "", "8080", HostPort{"server", "1234"}, "server:1234"},
 		{"server:", "9090", "8080", HostPort{"server", "9090"}, "server:9090"},
 		{"server:", "", "8080", HostPort{"server", "8080"}, "server:8080"},
+		{"[::1]", "", "8080", HostPort{"::1", "8080"}, "[::1]:8080"},
+		{"[::1]:1234", "9090", "8080", HostPort{"::1", "1234"}, "[::1]:1234"},
+		{"[::1]:", "9090", "8080", HostPort{"::1", "9090"}, "[::1]:9090"},
+		{"::1", "", "8080", HostPort{"::1", "8080"}, "[::1]:8080"},
+		{"fe80::1", "9090", "8080", HostPort{"fe80::1", "9090"}, "[fe80::1]:9090"},
+		{"fe80::1%eth0", "", "8080", HostPort{"fe80::1%eth0", "8080"}, "[fe80::1%eth0]:8080"},
+		{"[fe80::1%eth0]:1234", "", "8080", HostPort{"fe80::1%eth0", "1234"}, "[fe80::1%eth0]:1234"},
+		{"::ffff:127.0.0.1", "", "8080", HostPort{"::ffff:127.0.0.1", "8080"}, "[::ffff:127.0.0.1]:8080"},
 	}
 
 	for _, tt := range tests {
@@ -43,3 +52,177 @@ func TestNewHostPort_NoDefaultPort_Panic(t *testing.T) {
 		_ = NewHostPort("127.0.0.1", "", "")
 	})
 }
+
+func TestNewHostPort_InvalidAddress(t *testing.T) {
+	require.Equal(t, HostPort{Host: "server", Port: "1:2"}, NewHostPort("server:1:2", "", "8080"))
+	require.Equal(t, HostPort{Host: "[", Port: ":1"}, NewHostPort("[::1", "", "8080"))
+	require.Equal(t, "server:1:2", ComposeAddress("server:1:2", "", "8080"))
+}
+
+func TestParseHostPort_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		address     string
+		defaultPort string
+		wantErr     error
+	}{
+		"no-default-port": {
+			address: "server",
+			wantErr: ErrMissingDefaultPort,
+		},
+		"missing-bracket": {
+			address:     "[::1:8080",
+			defaultPort: "8080",
+			wantErr:     ErrMissingBracket,
+		},
+		"unexpected-bracket": {
+			address:     "::1]:8080",
+			defaultPort: "8080",
+			wantErr:     ErrUnexpectedBracket,
+		},
+		"unexpected-characters": {
+			address:     "[::1]8080",
+			defaultPort: "8080",
+			wantErr:     ErrUnexpectedCharacters,
+		},
+		"double-brackets": {
+			address:     "[::1]:[8080]",
+			defaultPort: "8080",
+			wantErr:     ErrUnexpectedCharacters,
+		},
+		"bracketed-hostname": {
+			address:     "[server]:8080",
+			defaultPort: "8080",
+			wantErr:     ErrInvalidIPv6,
+		},
+		"bracketed-ipv4": {
+			address:     "[127.0.0.1]:8080",
+			defaultPort: "8080",
+			wantErr:     ErrInvalidIPv6,
+		},
+		"too-many-colons": {
+			address:     "server:80:90",
+			defaultPort: "8080",
+			wantErr:     ErrTooManyColons,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseHostPort(tt.address, "", tt.defaultPort)
+
+			require.ErrorIs(t, err, tt.wantErr)
+
+			var addrErr *AddressError
+
+			require.ErrorAs(t, err, &addrErr)
+			require.Equal(t, tt.address, addrErr.Address)
+		})
+	}
+}
+
+func TestParseHostPort_Validation(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		address string
+		port    string
+		want    HostPort
+		wantErr error
+	}{
+		"hostname": {
+			address: "api-1.example.com.",
+			want:    HostPort{"api-1.example.com.", "8080"},
+		},
+		"ipv4": {
+			address: "10.0.0.1:0",
+			want:    HostPort{"10.0.0.1", "0"},
+		},
+		"ipv6": {
+			address: "[::1]:65535",
+			want:    HostPort{"::1", "65535"},
+		},
+		"named-port": {
+			address: "example.com:https",
+			want:    HostPort{"example.com", "443"},
+		},
+		"named-default": {
+			address: "example.com",
+			port:    "http",
+			want:    HostPort{"example.com", "80"},
+		},
+		"leading-zeros": {
+			address: ":0080",
+			want:    HostPort{"", "80"},
+		},
+		"surrounding-spaces": {
+			address: " example.com ",
+			want:    HostPort{"example.com", "8080"},
+		},
+		"inner-whitespace": {
+			address: "example .com",
+			wantErr: ErrWhitespace,
+		},
+		"port-whitespace": {
+			address: "example.com",
+			port:    "80 ",
+			wantErr: ErrWhitespace,
+		},
+		"port-range": {
+			address: "example.com:65536",
+			wantErr: ErrPortRange,
+		},
+		"negative-port": {
+			address: "example.com:-1",
+			wantErr: ErrInvalidPort,
+		},
+		"unknown-service": {
+			address: "example.com:no-such-service",
+			wantErr: ErrInvalidPort,
+		},
+		"invalid-hostname": {
+			address: "under_score.example.com",
+			wantErr: ErrInvalidHost,
+		},
+		"hyphen-label": {
+			address: "-api.example.com",
+			wantErr: ErrInvalidHost,
+		},
+		"long-label": {
+			address: strings.Repeat("a", 64) + ".com",
+			wantErr: ErrInvalidHost,
+		},
+		"invalid-ipv4": {
+			address: "256.0.0.1",
+			wantErr: ErrInvalidHost,
+		},
+		"multiple-colons": {
+			address: "example.com:80:80",
+			wantErr: ErrTooManyColons,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseHostPort(tt.address, tt.port, "8080")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewHostPort_NotValidated(t *testing.T) {
+	require.Equal(t, HostPort{"server", "http"}, NewHostPort("server:http", "", "8080"))
+}
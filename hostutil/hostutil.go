@@ -1,36 +1,127 @@
 package hostutil
 
 import (
-	"regexp"
+	"errors"
+	"strings"
 )
 
 // ComposeAddress composes host address from a specified address, port and a default port.
-// Supported formats for address are:
-//   - host
-//   - host:port
-//   - :port
-//   - host:
+// Supported formats for address are the ones supported by NewHostPort, including IPv6 addresses.
 //
 // The method inserts specified defaultPort if port is omitted in address provided.
-// The method panics if defaultPort is not specified.
+// The method panics if defaultPort is not specified. Use ParseHostPort to handle invalid addresses.
 // If address is empty, method will return address in form of ":defaultPort".
+// IPv6 addresses are returned in brackets, e.g. "[::1]:8080".
 func ComposeAddress(address, port, defaultPort string) string {
 	return NewHostPort(address, port, defaultPort).String()
 }
 
 // ComposeAddresses composes host addresses from a specified addresses, port and a default port.
-func ComposeAddresses(addresses []string, port, defaultPort string) []string {
-	composed := make([]string, len(addresses))
+// Addresses may be followed by weight and priority attributes, e.g. "host:port;w=5;p=1",
+// which are used by the ordering options. Unknown or malformed attributes are dropped.
+// The method panics if defaultPort is not specified.
+func ComposeAddresses(addresses []string, port, defaultPort string, opts ...ListOption) []string {
+	endpoints, err := composeEndpoints(addresses, port, defaultPort, false, opts)
+	if err != nil {
+		panic(err)
+	}
 
-	for i, addr := range addresses {
-		composed[i] = ComposeAddress(addr, port, defaultPort)
+	composed := make([]string, len(endpoints))
+
+	for i, e := range endpoints {
+		composed[i] = e.String()
 	}
 
 	return composed
 }
 
 // ComposeAddressList composes host addresses from a specified addresses, port and a default port.
-// Multiple addresses are delimited with comma or semi-column.
-func ComposeAddressList(addresses string, port, defaultPort string) []string {
-	return ComposeAddresses(regexp.MustCompile("[,;]").Split(addresses, -1), port, defaultPort)
+// Multiple addresses are delimited with comma or semi-column. A semi-column followed by a "name=value"
+// part starts an attribute of the preceding address instead of a new address, see ComposeAddresses.
+func ComposeAddressList(addresses string, port, defaultPort string, opts ...ListOption) []string {
+	return ComposeAddresses(splitAddressList(addresses), port, defaultPort, opts...)
+}
+
+// ParseHostPorts parses host addresses from a specified addresses, port and a default port.
+// Unlike ComposeAddresses, it validates the addresses the way ParseHostPort does it,
+// and returns errors for all addresses that cannot be parsed.
+func ParseHostPorts(addresses []string, port, defaultPort string, opts ...ListOption) ([]HostPort, error) {
+	endpoints, err := ParseEndpoints(addresses, port, defaultPort, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]HostPort, len(endpoints))
+
+	for i, e := range endpoints {
+		parsed[i] = e.HostPort
+	}
+
+	return parsed, nil
+}
+
+// ParseHostPortList parses host addresses from a specified addresses, port and a default port.
+// Multiple addresses are delimited with comma or semi-column. A semi-column followed by a "name=value"
+// part starts an attribute of the preceding address instead of a new address, see ParseEndpoints.
+func ParseHostPortList(addresses string, port, defaultPort string, opts ...ListOption) ([]HostPort, error) {
+	return ParseHostPorts(splitAddressList(addresses), port, defaultPort, opts...)
+}
+
+// ParseEndpoints parses addresses along with their weight and priority attributes,
+// e.g. "host:port;w=5;p=1". Weight defaults to 1 and priority defaults to 0.
+// Unknown or malformed attributes are returned as ErrInvalidAttribute.
+func ParseEndpoints(addresses []string, port, defaultPort string, opts ...ListOption) ([]Endpoint, error) {
+	return composeEndpoints(addresses, port, defaultPort, true, opts)
+}
+
+// ParseEndpointList parses addresses along with their weight and priority attributes.
+// Multiple addresses are delimited with comma or semi-column. A semi-column followed by a "name=value"
+// part starts an attribute of the preceding address instead of a new address.
+func ParseEndpointList(addresses string, port, defaultPort string, opts ...ListOption) ([]Endpoint, error) {
+	return ParseEndpoints(splitAddressList(addresses), port, defaultPort, opts...)
+}
+
+// composeEndpoints composes the endpoints of addresses. Strict mode validates the addresses and
+// their attributes, otherwise they are composed the way NewHostPort does it.
+func composeEndpoints(addresses []string, port, defaultPort string, strict bool, opts []ListOption) ([]Endpoint, error) {
+	o := &listOptions{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	entries, err := splitEntries(addresses, o.skipEmpty, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	parse := lenientHostPort
+	if strict {
+		parse = ParseHostPort
+	}
+
+	endpoints := make([]Endpoint, len(entries))
+
+	var errs []error
+
+	for i, entry := range entries {
+		hp, err := parse(entry.address, port, defaultPort)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		endpoints[i] = Endpoint{HostPort: hp, Priority: entry.priority, Weight: entry.weight}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return normalizeEndpoints(endpoints, o), nil
+}
+
+func splitAddressList(addresses string) []string {
+	return strings.Split(addresses, ",")
 }
@@ -21,6 +21,8 @@ func TestComposeAddress(t *testing.T) {
 		{"server:1234", "", "8080", "server:1234"},
 		{"server:", "9090", "8080", "server:9090"},
 		{"server:", "", "8080", "server:8080"},
+		{"[::1]:9090", "", "8080", "[::1]:9090"},
+		{"::1", "", "8080", "[::1]:8080"},
 	}
 
 	for _, tt := range tests {
@@ -91,3 +93,21 @@ func TestComposeAddressList_PortNotSet(t *testing.T) {
 		"server:9090",
 	}, composed)
 }
+
+func TestParseHostPortList(t *testing.T) {
+	parsed, err := ParseHostPortList("127.0.0.1; [::1]:9090, fe80::1%eth0", "", "8080")
+
+	require.NoError(t, err)
+	require.Equal(t, []HostPort{
+		{"127.0.0.1", "8080"},
+		{"::1", "9090"},
+		{"fe80::1%eth0", "8080"},
+	}, parsed)
+}
+
+func TestParseHostPortList_Error(t *testing.T) {
+	_, err := ParseHostPortList("server:1:2, [::1, ok", "", "8080")
+
+	require.ErrorIs(t, err, ErrTooManyColons)
+	require.ErrorIs(t, err, ErrMissingBracket)
+}
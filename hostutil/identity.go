@@ -0,0 +1,307 @@
+package hostutil
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Identity describes the host the process runs on.
+type Identity struct {
+	Hostname      string      `json:"hostname"`
+	FQDN          string      `json:"fqdn,omitempty"`
+	IP            netip.Addr  `json:"ip,omitzero"`
+	InstanceID    string      `json:"instanceId"`
+	Containerized bool        `json:"containerized"`
+	ContainerID   string      `json:"containerId,omitempty"`
+	Kubernetes    *Kubernetes `json:"kubernetes,omitempty"`
+}
+
+// Kubernetes describes the pod the process runs in. The values are read from the downward API
+// environment variables POD_NAME, POD_NAMESPACE, POD_IP and NODE_NAME, and the namespace falls
+// back to the service account namespace file.
+type Kubernetes struct {
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	PodIP     string `json:"podIp,omitempty"`
+	Node      string `json:"node,omitempty"`
+}
+
+// Fields returns the non-empty identity values keyed by log field names, e.g. for slog.SetBaseFields.
+func (id *Identity) Fields() map[string]any {
+	fields := map[string]any{}
+
+	add := func(key, value string) {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+
+	add("host", id.Hostname)
+	add("fqdn", id.FQDN)
+	add("instance_id", id.InstanceID)
+	add("container_id", id.ContainerID)
+
+	if id.IP.IsValid() {
+		fields["ip"] = id.IP.String()
+	}
+
+	if k8s := id.Kubernetes; k8s != nil {
+		add("k8s_namespace", k8s.Namespace)
+		add("k8s_pod", k8s.Pod)
+		add("k8s_pod_ip", k8s.PodIP)
+		add("k8s_node", k8s.Node)
+	}
+
+	return fields
+}
+
+// outboundTargets are the addresses used to pick the outbound interface. No traffic is sent to them.
+//
+//nolint:gochecknoglobals // constant list
+var outboundTargets = []string{"8.8.8.8:53", "[2001:4860:4860::8888]:53"}
+
+// identityProbe gathers the identity from the system. Its fields are replaceable for testing.
+type identityProbe struct {
+	root     string
+	getenv   func(key string) string
+	hostname func() (string, error)
+	resolver *net.Resolver
+	targets  []string
+}
+
+func newIdentityProbe() *identityProbe {
+	return &identityProbe{
+		root:     "/",
+		getenv:   os.Getenv,
+		hostname: os.Hostname,
+		resolver: net.DefaultResolver,
+		targets:  outboundTargets,
+	}
+}
+
+// LookupIdentity gathers the identity of the host. It is best effort: the identity is filled
+// with all values which could be determined, and errors for the others are joined.
+func LookupIdentity(ctx context.Context) (*Identity, error) {
+	return newIdentityProbe().identity(ctx)
+}
+
+// Hostname returns the host name reported by the kernel.
+func Hostname() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hostname: %w", err)
+	}
+
+	return hostname, nil
+}
+
+// FQDN returns the fully qualified domain name of the host, found by resolving the hostname
+// and reverse resolving its addresses. It returns the hostname if no qualified name is found.
+func FQDN(ctx context.Context) (string, error) {
+	return newIdentityProbe().fqdn(ctx)
+}
+
+// OutboundIP returns the local IP address used for outbound traffic. The address is determined
+// by the routing table, using a connected UDP socket, so no traffic is sent.
+func OutboundIP(ctx context.Context) (netip.Addr, error) {
+	return newIdentityProbe().outboundIP(ctx)
+}
+
+// InstanceID returns a stable identifier of the instance. It is taken from the INSTANCE_ID
+// environment variable if set, otherwise it is derived from the machine ID and the hostname,
+// so it survives restarts, and differs between containers sharing a machine.
+func InstanceID() (string, error) {
+	return newIdentityProbe().instanceID()
+}
+
+func (p *identityProbe) identity(ctx context.Context) (*Identity, error) {
+	var (
+		id   Identity
+		errs []error
+		err  error
+	)
+
+	if id.Hostname, err = p.hostname(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to get hostname: %w", err))
+	}
+
+	if id.FQDN, err = p.fqdn(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if id.IP, err = p.outboundIP(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if id.InstanceID, err = p.instanceID(); err != nil {
+		errs = append(errs, err)
+	}
+
+	id.ContainerID = p.containerID()
+	id.Kubernetes = p.kubernetes()
+	id.Containerized = id.ContainerID != "" || id.Kubernetes != nil ||
+		p.exists(".dockerenv") || p.exists("run/.containerenv")
+
+	return &id, errors.Join(errs...)
+}
+
+func (p *identityProbe) fqdn(ctx context.Context) (string, error) {
+	hostname, err := p.hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hostname: %w", err)
+	}
+
+	if strings.Contains(hostname, ".") {
+		return hostname, nil
+	}
+
+	addrs, err := p.resolver.LookupHost(ctx, hostname)
+	if err != nil {
+		return hostname, nil //nolint:nilerr // unresolvable hostname is not qualified
+	}
+
+	for _, addr := range addrs {
+		names, err := p.resolver.LookupAddr(ctx, addr)
+		if err != nil {
+			continue
+		}
+
+		for _, name := range names {
+			name = strings.TrimSuffix(name, ".")
+
+			if strings.Contains(name, ".") && !strings.HasPrefix(name, "localhost") {
+				return name, nil
+			}
+		}
+	}
+
+	return hostname, nil
+}
+
+func (p *identityProbe) outboundIP(ctx context.Context) (netip.Addr, error) {
+	var (
+		dialer net.Dialer
+		errs   []error
+	)
+
+	for _, target := range p.targets {
+		conn, err := dialer.DialContext(ctx, "udp", target)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		addr := conn.LocalAddr().(*net.UDPAddr).AddrPort().Addr().Unmap() //nolint:forcetypeassert // UDP network
+
+		_ = conn.Close()
+
+		return addr, nil
+	}
+
+	return netip.Addr{}, fmt.Errorf("failed to determine outbound IP: %w", errors.Join(errs...))
+}
+
+func (p *identityProbe) instanceID() (string, error) {
+	if id := p.getenv("INSTANCE_ID"); id != "" {
+		return id, nil
+	}
+
+	hostname, err := p.hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hostname: %w", err)
+	}
+
+	machineID := p.readFile("etc/machine-id")
+	if machineID == "" {
+		machineID = p.readFile("var/lib/dbus/machine-id")
+	}
+
+	sum := sha256.Sum256([]byte(machineID + "\x00" + hostname))
+
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// containerIDPattern matches container IDs used by Docker, containerd and CRI-O.
+//
+//nolint:gochecknoglobals // compiled once
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// containerID finds the container ID in the cgroup (v1) or mount (v2) information of the process.
+func (p *identityProbe) containerID() string {
+	for _, name := range []string{"proc/self/cgroup", "proc/self/mountinfo"} {
+		file, err := os.Open(filepath.Join(p.root, name))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			if !strings.Contains(line, "docker") && !strings.Contains(line, "containerd") &&
+				!strings.Contains(line, "crio") && !strings.Contains(line, "kubepods") {
+				continue
+			}
+
+			if id := containerIDPattern.FindString(line); id != "" {
+				_ = file.Close()
+
+				return id
+			}
+		}
+
+		_ = file.Close()
+	}
+
+	return ""
+}
+
+func (p *identityProbe) kubernetes() *Kubernetes {
+	if p.getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil
+	}
+
+	k8s := &Kubernetes{
+		Namespace: p.getenv("POD_NAMESPACE"),
+		Pod:       p.getenv("POD_NAME"),
+		PodIP:     p.getenv("POD_IP"),
+		Node:      p.getenv("NODE_NAME"),
+	}
+
+	if k8s.Namespace == "" {
+		k8s.Namespace = p.readFile("var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	}
+
+	if k8s.Pod == "" {
+		k8s.Pod, _ = p.hostname()
+	}
+
+	return k8s
+}
+
+func (p *identityProbe) readFile(name string) string {
+	content, err := os.ReadFile(filepath.Join(p.root, name))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(content))
+}
+
+func (p *identityProbe) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(p.root, name))
+
+	return err == nil
+}
@@ -0,0 +1,139 @@
+package hostutil
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProbe(t *testing.T, env map[string]string, files map[string]string) *identityProbe {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	return &identityProbe{
+		root:     root,
+		getenv:   func(key string) string { return env[key] },
+		hostname: func() (string, error) { return "web-1", nil },
+		resolver: net.DefaultResolver,
+		targets:  []string{"127.0.0.1:9"},
+	}
+}
+
+func TestIdentity_Kubernetes(t *testing.T) {
+	t.Parallel()
+
+	const containerID = "3f4e8b2a9c1d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f"
+
+	probe := newTestProbe(t, map[string]string{
+		"KUBERNETES_SERVICE_HOST": "10.96.0.1",
+		"POD_NAME":                "web-1",
+		"NODE_NAME":               "node-a",
+	}, map[string]string{
+		"etc/machine-id":   "0123456789abcdef\n",
+		"proc/self/cgroup": "0::/kubepods/besteffort/pod1234/cri-containerd-" + containerID + ".scope\n",
+		"var/run/secrets/kubernetes.io/serviceaccount/namespace": "shop\n",
+	})
+
+	id, err := probe.identity(t.Context())
+
+	require.NoError(t, err)
+	require.Equal(t, "web-1", id.Hostname)
+	require.Equal(t, "127.0.0.1", id.IP.String())
+	require.True(t, id.Containerized)
+	require.Equal(t, containerID, id.ContainerID)
+	require.Equal(t, &Kubernetes{Namespace: "shop", Pod: "web-1", Node: "node-a"}, id.Kubernetes)
+	require.Len(t, id.InstanceID, 16)
+
+	fields := id.Fields()
+
+	require.Equal(t, "shop", fields["k8s_namespace"])
+	require.Equal(t, "node-a", fields["k8s_node"])
+	require.Equal(t, containerID, fields["container_id"])
+	require.NotContains(t, fields, "k8s_pod_ip")
+}
+
+func TestIdentity_Host(t *testing.T) {
+	t.Parallel()
+
+	probe := newTestProbe(t, nil, map[string]string{
+		"proc/self/cgroup": "0::/user.slice/user-1000.slice\n",
+	})
+
+	id, err := probe.identity(t.Context())
+
+	require.NoError(t, err)
+	require.False(t, id.Containerized)
+	require.Empty(t, id.ContainerID)
+	require.Nil(t, id.Kubernetes)
+
+	docker := newTestProbe(t, nil, map[string]string{".dockerenv": ""})
+
+	id, err = docker.identity(t.Context())
+
+	require.NoError(t, err)
+	require.True(t, id.Containerized)
+}
+
+func TestInstanceID(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{"etc/machine-id": "0123456789abcdef\n"}
+
+	first, err := newTestProbe(t, nil, files).instanceID()
+	require.NoError(t, err)
+
+	second, err := newTestProbe(t, nil, files).instanceID()
+	require.NoError(t, err)
+
+	require.Equal(t, first, second)
+
+	other := newTestProbe(t, nil, files)
+	other.hostname = func() (string, error) { return "web-2", nil }
+
+	third, err := other.instanceID()
+
+	require.NoError(t, err)
+	require.NotEqual(t, first, third)
+
+	explicit, err := newTestProbe(t, map[string]string{"INSTANCE_ID": "i-123"}, files).instanceID()
+
+	require.NoError(t, err)
+	require.Equal(t, "i-123", explicit)
+}
+
+func TestFQDN(t *testing.T) {
+	t.Parallel()
+
+	probe := newTestProbe(t, nil, nil)
+	probe.hostname = func() (string, error) { return "web-1.example.com", nil }
+
+	fqdn, err := probe.fqdn(t.Context())
+
+	require.NoError(t, err)
+	require.Equal(t, "web-1.example.com", fqdn)
+
+	fqdn, err = FQDN(t.Context())
+
+	require.NoError(t, err)
+	require.NotEmpty(t, fqdn)
+}
+
+func TestLookupIdentity(t *testing.T) {
+	t.Parallel()
+
+	id, _ := LookupIdentity(t.Context())
+
+	require.NotEmpty(t, id.Hostname)
+	require.NotEmpty(t, id.InstanceID)
+}
@@ -0,0 +1,156 @@
+package hostutil
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Bind specification keywords.
+const (
+	BindLoopback = "loopback"
+	BindPrivate  = "private"
+	BindPublic   = "public"
+	BindAll      = "all"
+)
+
+// interfaceAddr is an address assigned to a local network interface.
+type interfaceAddr struct {
+	iface string
+	addr  netip.Addr
+}
+
+// ResolveBindAddresses resolves a comma-separated bind specification into concrete host addresses
+// of the local network interfaces. Each entry of the specification is one of:
+//   - an interface name (e.g. "eth0"): all addresses of the interface, including link-local ones,
+//   - "loopback": loopback addresses,
+//   - "private": private addresses (RFC 1918, RFC 4193),
+//   - "public": global unicast addresses which are not private,
+//   - "all": all addresses except link-local ones,
+//   - a CIDR (e.g. "10.0.0.0/8"): addresses within the prefix,
+//   - anything else (IP address or hostname): used as is.
+//
+// Only interfaces which are up are considered. Link-local IPv6 addresses carry the interface zone.
+// Duplicate addresses are dropped. Empty specification results in an empty list.
+func ResolveBindAddresses(spec string) ([]string, error) {
+	return resolveBindAddresses(spec, localInterfaceAddrs)
+}
+
+func resolveBindAddresses(spec string, list func() ([]interfaceAddr, error)) ([]string, error) {
+	var (
+		hosts []string
+		addrs []interfaceAddr
+	)
+
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if addrs == nil {
+			var err error
+
+			if addrs, err = list(); err != nil {
+				return nil, fmt.Errorf("failed to list interface addresses: %w", err)
+			}
+		}
+
+		matched, ok := matchBindEntry(entry, addrs)
+		if !ok {
+			matched = []string{entry}
+		}
+
+		if len(matched) == 0 {
+			return nil, &AddressError{Address: entry, Err: ErrNoInterfaceAddresses}
+		}
+
+		for _, host := range matched {
+			if !slices.Contains(hosts, host) {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	return hosts, nil
+}
+
+// matchBindEntry returns addresses matching the entry. It returns false if the entry is
+// neither a keyword, a CIDR nor an interface name.
+func matchBindEntry(entry string, addrs []interfaceAddr) ([]string, bool) {
+	var match func(ia interfaceAddr) bool
+
+	switch strings.ToLower(entry) {
+	case BindLoopback:
+		match = func(ia interfaceAddr) bool { return ia.addr.IsLoopback() }
+	case BindPrivate:
+		match = func(ia interfaceAddr) bool { return ia.addr.IsPrivate() }
+	case BindPublic:
+		match = func(ia interfaceAddr) bool { return ia.addr.IsGlobalUnicast() && !ia.addr.IsPrivate() }
+	case BindAll:
+		match = func(ia interfaceAddr) bool { return !ia.addr.IsLinkLocalUnicast() }
+	default:
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			match = func(ia interfaceAddr) bool { return prefix.Contains(ia.addr.WithZone("")) }
+		} else if slices.ContainsFunc(addrs, func(ia interfaceAddr) bool { return ia.iface == entry }) {
+			match = func(ia interfaceAddr) bool { return ia.iface == entry }
+		} else {
+			return nil, false
+		}
+	}
+
+	var hosts []string
+
+	for _, ia := range addrs {
+		if match(ia) {
+			hosts = append(hosts, ia.addr.String())
+		}
+	}
+
+	return hosts, true
+}
+
+// localInterfaceAddrs lists addresses of the local network interfaces which are up.
+func localInterfaceAddrs() ([]interfaceAddr, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by the caller
+	}
+
+	var addrs []interfaceAddr
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err //nolint:wrapcheck // wrapped by the caller
+		}
+
+		for _, a := range ifaceAddrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+
+			addr, ok := netip.AddrFromSlice(ipNet.IP)
+			if !ok {
+				continue
+			}
+
+			addr = addr.Unmap()
+
+			if addr.Is6() && addr.IsLinkLocalUnicast() {
+				addr = addr.WithZone(iface.Name)
+			}
+
+			addrs = append(addrs, interfaceAddr{iface: iface.Name, addr: addr})
+		}
+	}
+
+	return addrs, nil
+}
@@ -0,0 +1,81 @@
+package hostutil
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBindAddresses(t *testing.T) {
+	t.Parallel()
+
+	list := func() ([]interfaceAddr, error) {
+		return []interfaceAddr{
+			{iface: "lo", addr: netip.MustParseAddr("127.0.0.1")},
+			{iface: "lo", addr: netip.MustParseAddr("::1")},
+			{iface: "eth0", addr: netip.MustParseAddr("10.0.0.5")},
+			{iface: "eth0", addr: netip.MustParseAddr("fe80::1%eth0")},
+			{iface: "eth0", addr: netip.MustParseAddr("fd00::5")},
+			{iface: "eth1", addr: netip.MustParseAddr("203.0.113.7")},
+			{iface: "eth1", addr: netip.MustParseAddr("2001:db8::7")},
+		}, nil
+	}
+
+	tests := map[string]struct {
+		spec string
+		want []string
+	}{
+		"empty":     {"", nil},
+		"loopback":  {"loopback", []string{"127.0.0.1", "::1"}},
+		"private":   {"private", []string{"10.0.0.5", "fd00::5"}},
+		"public":    {"PUBLIC", []string{"203.0.113.7", "2001:db8::7"}},
+		"all":       {"all", []string{"127.0.0.1", "::1", "10.0.0.5", "fd00::5", "203.0.113.7", "2001:db8::7"}},
+		"interface": {"eth0", []string{"10.0.0.5", "fe80::1%eth0", "fd00::5"}},
+		"cidr-v4":   {"10.0.0.0/8", []string{"10.0.0.5"}},
+		"cidr-v6":   {"fe80::/10", []string{"fe80::1%eth0"}},
+		"host":      {"localhost", []string{"localhost"}},
+		"ip":        {"0.0.0.0", []string{"0.0.0.0"}},
+		"list":      {"lo, eth1,127.0.0.1", []string{"127.0.0.1", "::1", "203.0.113.7", "2001:db8::7"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hosts, err := resolveBindAddresses(tt.spec, list)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, hosts)
+		})
+	}
+}
+
+func TestResolveBindAddresses_Errors(t *testing.T) {
+	t.Parallel()
+
+	list := func() ([]interfaceAddr, error) {
+		return []interfaceAddr{{iface: "lo", addr: netip.MustParseAddr("127.0.0.1")}}, nil
+	}
+
+	_, err := resolveBindAddresses("public", list)
+	require.ErrorIs(t, err, ErrNoInterfaceAddresses)
+
+	_, err = resolveBindAddresses("192.168.0.0/16", list)
+	require.ErrorIs(t, err, ErrNoInterfaceAddresses)
+
+	errList := errors.New("boom")
+
+	_, err = resolveBindAddresses("lo", func() ([]interfaceAddr, error) { return nil, errList })
+	require.ErrorIs(t, err, errList)
+}
+
+func TestResolveBindAddresses_Local(t *testing.T) {
+	t.Parallel()
+
+	hosts, err := ResolveBindAddresses("loopback")
+
+	require.NoError(t, err)
+	require.Contains(t, hosts, "127.0.0.1")
+}
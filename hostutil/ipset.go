@@ -0,0 +1,229 @@
+package hostutil
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// IPSet is an immutable set of IP addresses built from CIDR prefixes and single addresses.
+// Prefixes are merged into sorted address ranges, so containment checks take logarithmic time
+// regardless of the number of prefixes. The zero value is an empty set.
+type IPSet struct {
+	prefixes []netip.Prefix
+	ranges   []ipRange
+}
+
+type ipRange struct {
+	from netip.Addr
+	to   netip.Addr
+}
+
+// NewIPSet creates a new IPSet from the prefixes.
+func NewIPSet(prefixes ...netip.Prefix) *IPSet {
+	set := &IPSet{prefixes: make([]netip.Prefix, 0, len(prefixes))}
+
+	for _, prefix := range prefixes {
+		if prefix.Addr().Is4In6() {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), max(prefix.Bits()-96, 0)) //nolint:mnd // IPv4-mapped prefix length
+		}
+
+		prefix = prefix.Masked()
+
+		if prefix.IsValid() && !slices.Contains(set.prefixes, prefix) {
+			set.prefixes = append(set.prefixes, prefix)
+		}
+	}
+
+	ranges := make([]ipRange, len(set.prefixes))
+
+	for i, prefix := range set.prefixes {
+		ranges[i] = ipRange{from: prefix.Addr(), to: lastAddr(prefix)}
+	}
+
+	slices.SortFunc(ranges, func(a, b ipRange) int { return a.from.Compare(b.from) })
+
+	for _, r := range ranges {
+		if n := len(set.ranges); n > 0 && set.ranges[n-1].adjoins(r) {
+			if r.to.Compare(set.ranges[n-1].to) > 0 {
+				set.ranges[n-1].to = r.to
+			}
+
+			continue
+		}
+
+		set.ranges = append(set.ranges, r)
+	}
+
+	return set
+}
+
+// ParseIPSet parses a comma-separated list of CIDR prefixes and IP addresses (IPv4 and IPv6).
+// Empty entries are ignored.
+func ParseIPSet(s string) (*IPSet, error) {
+	var prefixes []netip.Prefix
+
+	for entry := range strings.SplitSeq(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return nil, &AddressError{Address: entry, Err: err}
+		}
+
+		prefixes = append(prefixes, prefix)
+	}
+
+	return NewIPSet(prefixes...), nil
+}
+
+// MustParseIPSet is like ParseIPSet, but panics on error.
+func MustParseIPSet(s string) *IPSet {
+	set, err := ParseIPSet(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return set
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%w: %w", ErrInvalidHost, err)
+		}
+
+		return prefix, nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %w", ErrInvalidHost, err)
+	}
+
+	addr = addr.WithZone("")
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Contains reports whether the address is in the set. IPv4-mapped IPv6 addresses are matched
+// as IPv4 addresses, and zones are ignored.
+func (s *IPSet) Contains(addr netip.Addr) bool {
+	if s == nil || !addr.IsValid() {
+		return false
+	}
+
+	addr = addr.Unmap().WithZone("")
+
+	i := sort.Search(len(s.ranges), func(i int) bool { return s.ranges[i].to.Compare(addr) >= 0 })
+
+	return i < len(s.ranges) && s.ranges[i].from.Compare(addr) <= 0
+}
+
+// IsEmpty reports whether the set contains no addresses.
+func (s *IPSet) IsEmpty() bool {
+	return s == nil || len(s.ranges) == 0
+}
+
+// Prefixes returns the prefixes the set was built from, in their canonical form.
+func (s *IPSet) Prefixes() []netip.Prefix {
+	if s == nil {
+		return nil
+	}
+
+	return slices.Clone(s.prefixes)
+}
+
+// String returns the comma-separated list of prefixes. Single addresses are formatted without prefix length.
+func (s *IPSet) String() string {
+	if s == nil {
+		return ""
+	}
+
+	entries := make([]string, len(s.prefixes))
+
+	for i, prefix := range s.prefixes {
+		if prefix.IsSingleIP() {
+			entries[i] = prefix.Addr().String()
+		} else {
+			entries[i] = prefix.String()
+		}
+	}
+
+	return strings.Join(entries, ",")
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s *IPSet) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *IPSet) UnmarshalText(text []byte) error {
+	set, err := ParseIPSet(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = *set
+
+	return nil
+}
+
+// adjoins reports whether the next range, which does not start before r, overlaps or directly follows r.
+func (r ipRange) adjoins(next ipRange) bool {
+	if r.from.Is4() != next.from.Is4() {
+		return false
+	}
+
+	following := r.to.Next()
+
+	return !following.IsValid() || next.from.Compare(following) <= 0
+}
+
+// lastAddr returns the last address of the prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Addr().AsSlice()
+
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 1 << (7 - bit%8)
+	}
+
+	addr, _ := netip.AddrFromSlice(bytes)
+
+	return addr
+}
+
+// Precedence defines how IPPolicy resolves addresses matching both or neither of its sets.
+type Precedence int
+
+const (
+	// DenyOverrides denies addresses that are not allowed, and addresses that are denied even if allowed.
+	DenyOverrides Precedence = iota
+
+	// AllowOverrides allows addresses that are not denied, and addresses that are allowed even if denied.
+	AllowOverrides
+)
+
+// IPPolicy decides whether an address is allowed, based on allow and deny sets.
+// With DenyOverrides, an empty allow set allows all addresses.
+type IPPolicy struct {
+	Allow      *IPSet
+	Deny       *IPSet
+	Precedence Precedence
+}
+
+// Allowed reports whether the address is allowed by the policy.
+func (p IPPolicy) Allowed(addr netip.Addr) bool {
+	if p.Precedence == AllowOverrides {
+		return p.Allow.Contains(addr) || !p.Deny.Contains(addr)
+	}
+
+	return (p.Allow.IsEmpty() || p.Allow.Contains(addr)) && !p.Deny.Contains(addr)
+}
@@ -0,0 +1,130 @@
+package hostutil
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPSet(t *testing.T) {
+	t.Parallel()
+
+	set, err := ParseIPSet(" 10.0.0.0/8, 192.168.1.10,, fd00::/8 ,2001:db8::1, 10.1.2.3/16")
+
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.0/8,192.168.1.10,fd00::/8,2001:db8::1,10.1.0.0/16", set.String())
+
+	tests := map[string]bool{
+		"10.0.0.0":             true,
+		"10.255.255.255":       true,
+		"11.0.0.0":             false,
+		"9.255.255.255":        false,
+		"192.168.1.10":         true,
+		"192.168.1.11":         false,
+		"::ffff:10.1.1.1":      true,
+		"fd12:3456::1":         true,
+		"fe80::1%eth0":         false,
+		"2001:db8::1":          true,
+		"2001:db8::2":          false,
+		"::":                   false,
+		"ffff:ffff::ffff:ffff": false,
+	}
+
+	for addr, want := range tests {
+		require.Equal(t, want, set.Contains(netip.MustParseAddr(addr)), addr)
+	}
+
+	_, err = ParseIPSet("10.0.0.0/33")
+	require.ErrorIs(t, err, ErrInvalidHost)
+
+	_, err = ParseIPSet("example.com")
+	require.ErrorIs(t, err, ErrInvalidHost)
+}
+
+func TestIPSet_Merge(t *testing.T) {
+	t.Parallel()
+
+	set := MustParseIPSet("10.0.0.0/25,10.0.0.128/25,10.0.0.64/26,10.0.1.0/24,0.0.0.0/0,::/0")
+
+	require.Equal(t, []ipRange{
+		{netip.MustParseAddr("0.0.0.0"), netip.MustParseAddr("255.255.255.255")},
+		{netip.MustParseAddr("::"), netip.MustParseAddr("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")},
+	}, set.ranges)
+
+	set = MustParseIPSet("10.0.0.0/25,10.0.0.128/25,10.0.2.0/24")
+
+	require.Equal(t, []ipRange{
+		{netip.MustParseAddr("10.0.0.0"), netip.MustParseAddr("10.0.0.255")},
+		{netip.MustParseAddr("10.0.2.0"), netip.MustParseAddr("10.0.2.255")},
+	}, set.ranges)
+}
+
+func TestIPSet_Empty(t *testing.T) {
+	t.Parallel()
+
+	var zero IPSet
+
+	require.True(t, zero.IsEmpty())
+	require.False(t, zero.Contains(netip.MustParseAddr("10.0.0.1")))
+
+	var nilSet *IPSet
+
+	require.True(t, nilSet.IsEmpty())
+	require.False(t, nilSet.Contains(netip.MustParseAddr("10.0.0.1")))
+	require.Empty(t, nilSet.String())
+}
+
+func TestIPSet_UnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	var set IPSet
+
+	require.NoError(t, set.UnmarshalText([]byte("127.0.0.1,::1")))
+	require.True(t, set.Contains(netip.MustParseAddr("::1")))
+
+	text, err := set.MarshalText()
+
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1,::1", string(text))
+	require.Error(t, set.UnmarshalText([]byte("bad")))
+}
+
+func TestIPPolicy(t *testing.T) {
+	t.Parallel()
+
+	allow := MustParseIPSet("10.0.0.0/8")
+	deny := MustParseIPSet("10.0.0.13,203.0.113.0/24")
+
+	tests := map[string]struct {
+		policy IPPolicy
+		want   map[string]bool
+	}{
+		"deny-overrides": {
+			policy: IPPolicy{Allow: allow, Deny: deny},
+			want:   map[string]bool{"10.0.0.1": true, "10.0.0.13": false, "203.0.113.1": false, "198.51.100.1": false},
+		},
+		"allow-overrides": {
+			policy: IPPolicy{Allow: allow, Deny: deny, Precedence: AllowOverrides},
+			want:   map[string]bool{"10.0.0.1": true, "10.0.0.13": true, "203.0.113.1": false, "198.51.100.1": true},
+		},
+		"deny-only": {
+			policy: IPPolicy{Deny: deny},
+			want:   map[string]bool{"10.0.0.1": true, "10.0.0.13": false, "198.51.100.1": true},
+		},
+		"allow-only": {
+			policy: IPPolicy{Allow: allow},
+			want:   map[string]bool{"10.0.0.13": true, "198.51.100.1": false},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for addr, want := range tt.want {
+				require.Equal(t, want, tt.policy.Allowed(netip.MustParseAddr(addr)), addr)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package hostutil
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const defaultWeight = 1
+
+// Endpoint is an address with a weight and a priority, similar to SRV records.
+// Endpoints with lower priority are preferred. Among the endpoints with the same
+// priority, the ones with larger weight are preferred.
+type Endpoint struct {
+	HostPort
+
+	Priority int
+	Weight   int
+}
+
+// ListOption configures normalization of address lists.
+type ListOption func(o *listOptions)
+
+type listOptions struct {
+	skipEmpty   bool
+	deduplicate bool
+	sort        bool
+	shuffle     bool
+	seed        uint64
+}
+
+// WithSkipEmpty drops empty and whitespace-only entries,
+// instead of composing them into the default address ":port".
+func WithSkipEmpty() ListOption {
+	return func(o *listOptions) {
+		o.skipEmpty = true
+	}
+}
+
+// WithDeduplication drops entries equal to a preceding entry. Entries are compared
+// after normalization, i.e. with the default port applied, host names lowercased and
+// IP addresses in canonical form.
+func WithDeduplication() ListOption {
+	return func(o *listOptions) {
+		o.deduplicate = true
+	}
+}
+
+// WithSorting orders the entries deterministically, by priority, weight (larger first) and address.
+func WithSorting() ListOption {
+	return func(o *listOptions) {
+		o.sort = true
+		o.shuffle = false
+	}
+}
+
+// WithWeightedShuffle orders the entries by priority, and shuffles the entries with
+// the same priority, so the ones with larger weight are more likely to come first,
+// as described in RFC 2782. The same seed always produces the same order.
+func WithWeightedShuffle(seed uint64) ListOption {
+	return func(o *listOptions) {
+		o.shuffle = true
+		o.sort = false
+		o.seed = seed
+	}
+}
+
+// listEntry is an address with attributes, not parsed yet.
+type listEntry struct {
+	address  string
+	priority int
+	weight   int
+}
+
+// splitEntries splits addresses delimited with comma or semi-column. An address may be followed by
+// attributes delimited with semi-column, e.g. "host:port;w=5;p=1". Supported attributes are
+// weight (w) and priority (p). As semi-column delimits both addresses and attributes, a part
+// containing "=" is always taken for an attribute, and a part without it for the next address.
+// Unknown or malformed attributes are errors if strict is set, and are dropped otherwise.
+func splitEntries(addresses []string, skipEmpty, strict bool) ([]listEntry, error) {
+	entries := make([]listEntry, 0, len(addresses))
+
+	for _, item := range addresses {
+		for i, part := range strings.Split(item, ";") {
+			if i == 0 || !strings.Contains(part, "=") {
+				entries = append(entries, listEntry{address: part, weight: defaultWeight})
+
+				continue
+			}
+
+			if err := parseAttribute(&entries[len(entries)-1], part); err != nil && strict {
+				return nil, &AddressError{Address: item, Err: err}
+			}
+		}
+	}
+
+	if skipEmpty {
+		entries = slices.DeleteFunc(entries, func(e listEntry) bool {
+			return strings.TrimSpace(e.address) == ""
+		})
+	}
+
+	return entries, nil
+}
+
+// parseAttribute sets the attribute of the entry from a "name=value" part.
+func parseAttribute(entry *listEntry, part string) error {
+	name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+	var target *int
+
+	switch strings.TrimSpace(name) {
+	case "w", "weight":
+		target = &entry.weight
+	case "p", "priority":
+		target = &entry.priority
+	default:
+		return fmt.Errorf("%w: unknown attribute %q", ErrInvalidAttribute, part)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidAttribute, part)
+	}
+
+	*target = n
+
+	return nil
+}
+
+// normalizeEndpoints applies deduplication and ordering options.
+func normalizeEndpoints(endpoints []Endpoint, o *listOptions) []Endpoint {
+	if o.deduplicate {
+		seen := make(map[HostPort]bool, len(endpoints))
+
+		endpoints = slices.DeleteFunc(endpoints, func(e Endpoint) bool {
+			key := normalizeHostPort(e.HostPort)
+			if seen[key] {
+				return true
+			}
+
+			seen[key] = true
+
+			return false
+		})
+	}
+
+	switch {
+	case o.sort:
+		slices.SortStableFunc(endpoints, func(a, b Endpoint) int {
+			return cmp.Or(
+				cmp.Compare(a.Priority, b.Priority),
+				cmp.Compare(b.Weight, a.Weight),
+				cmp.Compare(a.String(), b.String()),
+			)
+		})
+	case o.shuffle:
+		endpoints = weightedShuffle(endpoints, rand.New(rand.NewPCG(o.seed, 0))) //nolint:gosec // not used for security
+	}
+
+	return endpoints
+}
+
+// normalizeHostPort lowercases the host name and converts IP addresses to canonical form.
+func normalizeHostPort(hp HostPort) HostPort {
+	if addr, err := netip.ParseAddr(hp.Host); err == nil {
+		return HostPort{Host: addr.String(), Port: hp.Port}
+	}
+
+	return HostPort{Host: strings.ToLower(strings.TrimSuffix(hp.Host, ".")), Port: hp.Port}
+}
+
+// weightedShuffle orders the endpoints by priority, and within the same priority
+// selects them randomly, proportionally to their weight.
+func weightedShuffle(endpoints []Endpoint, rnd *rand.Rand) []Endpoint {
+	remaining := slices.Clone(endpoints)
+
+	slices.SortStableFunc(remaining, func(a, b Endpoint) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	})
+
+	shuffled := make([]Endpoint, 0, len(remaining))
+
+	for len(remaining) > 0 {
+		// the group of endpoints with the lowest priority
+		group := 1
+		for group < len(remaining) && remaining[group].Priority == remaining[0].Priority {
+			group++
+		}
+
+		total := 0
+		for _, e := range remaining[:group] {
+			total += e.Weight
+		}
+
+		selected := rnd.IntN(group)
+
+		if total > 0 {
+			r := rnd.IntN(total)
+
+			for i, e := range remaining[:group] {
+				if r < e.Weight {
+					selected = i
+
+					break
+				}
+
+				r -= e.Weight
+			}
+		}
+
+		shuffled = append(shuffled, remaining[selected])
+		remaining = slices.Delete(remaining, selected, selected+1)
+	}
+
+	return shuffled
+}
@@ -0,0 +1,123 @@
+package hostutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeAddressList_Options(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		addresses string
+		opts      []ListOption
+		want      []string
+	}{
+		"no-options": {
+			addresses: "a,,b; ",
+			want:      []string{"a:8080", ":8080", "b:8080", ":8080"},
+		},
+		"skip-empty": {
+			addresses: "a,,b; ",
+			opts:      []ListOption{WithSkipEmpty()},
+			want:      []string{"a:8080", "b:8080"},
+		},
+		"deduplicate": {
+			addresses: "Server, server:8080, server., 10.0.0.1, [::0001], ::1, 10.0.0.1:9090",
+			opts:      []ListOption{WithDeduplication()},
+			want:      []string{"Server:8080", "10.0.0.1:8080", "[::0001]:8080", "10.0.0.1:9090"},
+		},
+		"attributes-dropped": {
+			addresses: "a;w=5;p=1, b;weight=2, c",
+			want:      []string{"a:8080", "b:8080", "c:8080"},
+		},
+		"sorted": {
+			addresses: "d;p=1, c;w=1, b;w=5, a;w=1",
+			opts:      []ListOption{WithSorting()},
+			want:      []string{"b:8080", "a:8080", "c:8080", "d:8080"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, ComposeAddressList(tt.addresses, "", "8080", tt.opts...))
+		})
+	}
+}
+
+func TestComposeAddressList_InvalidAttribute(t *testing.T) {
+	require.Equal(t, []string{"a:8080", "b:8080"}, ComposeAddressList("a;w=x;foo=bar, b", "", "8080"))
+	require.Equal(t, []string{"host:8080"}, ComposeAddresses([]string{"host;w=x"}, "", "8080"))
+}
+
+func TestParseEndpointList(t *testing.T) {
+	t.Parallel()
+
+	endpoints, err := ParseEndpointList("a:1;w=5;p=2, b:http, c;priority=1", "", "8080")
+
+	require.NoError(t, err)
+	require.Equal(t, []Endpoint{
+		{HostPort: HostPort{"a", "1"}, Priority: 2, Weight: 5},
+		{HostPort: HostPort{"b", "80"}, Priority: 0, Weight: 1},
+		{HostPort: HostPort{"c", "8080"}, Priority: 1, Weight: 1},
+	}, endpoints)
+}
+
+func TestParseEndpointList_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseEndpointList("a;w=-1", "", "8080")
+	require.ErrorIs(t, err, ErrInvalidAttribute)
+
+	_, err = ParseEndpointList("a;foo=bar", "", "8080")
+	require.ErrorIs(t, err, ErrInvalidAttribute)
+
+	_, err = ParseEndpointList("a,,b", "", "8080")
+	require.NoError(t, err, "empty entries compose into the default address")
+
+	_, err = ParseEndpointList("a, bad_host", "", "8080")
+	require.ErrorIs(t, err, ErrInvalidHost)
+}
+
+func TestWithWeightedShuffle(t *testing.T) {
+	t.Parallel()
+
+	const addresses = "a;w=1, b;w=10, c;w=0, d;p=1, e;p=1"
+
+	shuffle := func(seed uint64) []string {
+		return ComposeAddressList(addresses, "", "8080", WithWeightedShuffle(seed))
+	}
+
+	t.Run("deterministic", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, shuffle(42), shuffle(42))
+	})
+
+	t.Run("priorities", func(t *testing.T) {
+		t.Parallel()
+
+		for seed := range uint64(20) {
+			got := shuffle(seed)
+
+			require.ElementsMatch(t, []string{"a:8080", "b:8080", "c:8080"}, got[:3])
+			require.ElementsMatch(t, []string{"d:8080", "e:8080"}, got[3:])
+		}
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		t.Parallel()
+
+		first := map[string]int{}
+
+		for seed := range uint64(200) {
+			first[shuffle(seed)[0]]++
+		}
+
+		require.Greater(t, first["b:8080"], first["a:8080"])
+		require.Zero(t, first["c:8080"], "zero weight is selected only if nothing else is left")
+	})
+}
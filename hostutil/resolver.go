@@ -0,0 +1,221 @@
+package hostutil
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver resolves host names to IP addresses.
+type Resolver interface {
+	Resolve(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+// NetResolver is a Resolver backed by net.Resolver.
+type NetResolver struct {
+	resolver *net.Resolver
+}
+
+// NewNetResolver creates a new NetResolver. If resolver is nil, net.DefaultResolver is used.
+func NewNetResolver(resolver *net.Resolver) *NetResolver {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &NetResolver{resolver: resolver}
+}
+
+// Resolve implements Resolver. IPv4-mapped IPv6 addresses are converted to IPv4 addresses.
+func (r *NetResolver) Resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	addrs, err := r.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // net.DNSError is descriptive enough
+	}
+
+	for i, addr := range addrs {
+		addrs[i] = addr.Unmap()
+	}
+
+	return addrs, nil
+}
+
+// CachingResolver caches the results of another Resolver. Failed lookups are cached too,
+// for a separate (usually shorter) period. Cancelled lookups are not cached.
+type CachingResolver struct {
+	next        Resolver
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	addrs     []netip.Addr
+	err       error
+	expiresAt time.Time
+}
+
+// NewCachingResolver creates a new CachingResolver caching successful lookups for ttl,
+// and failed lookups for negativeTTL. Zero negativeTTL disables caching of failed lookups.
+func NewCachingResolver(next Resolver, ttl, negativeTTL time.Duration) *CachingResolver {
+	return &CachingResolver{
+		next:        next,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+		entries:     make(map[string]cacheEntry),
+	}
+}
+
+// Resolve implements Resolver.
+func (r *CachingResolver) Resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	key := strings.ToLower(host)
+
+	r.mu.Lock()
+	entry, ok := r.entries[key]
+	r.mu.Unlock()
+
+	if ok && r.now().Before(entry.expiresAt) {
+		return slices.Clone(entry.addrs), entry.err
+	}
+
+	addrs, err := r.next.Resolve(ctx, host)
+
+	ttl := r.ttl
+	if err != nil {
+		ttl = r.negativeTTL
+	}
+
+	if ttl > 0 && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		r.mu.Lock()
+		r.entries[key] = cacheEntry{addrs: slices.Clone(addrs), err: err, expiresAt: r.now().Add(ttl)}
+		r.mu.Unlock()
+	}
+
+	return addrs, err
+}
+
+// Flush removes all cached entries.
+func (r *CachingResolver) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clear(r.entries)
+}
+
+// StaticResolver resolves host names from a static table. Host names are case-insensitive,
+// and have to be stored lowercased. It is useful in tests, and as a hosts-file stand-in.
+type StaticResolver map[string][]netip.Addr
+
+// Resolve implements Resolver. It returns net.DNSError if the host is not found.
+func (r StaticResolver) Resolve(_ context.Context, host string) ([]netip.Addr, error) {
+	addrs, ok := r[strings.ToLower(strings.TrimSuffix(host, "."))]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return slices.Clone(addrs), nil
+}
+
+// ParseHosts parses content in hosts file format (e.g. /etc/hosts) into a StaticResolver.
+// Each line contains an IP address followed by host names. Comments start with "#".
+func ParseHosts(reader io.Reader) (StaticResolver, error) {
+	resolver := StaticResolver{}
+	scanner := bufio.NewScanner(reader)
+
+	for line := 1; scanner.Scan(); line++ {
+		content, _, _ := strings.Cut(scanner.Text(), "#")
+
+		fields := strings.Fields(content)
+		if len(fields) == 0 {
+			continue
+		}
+
+		addr, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		for _, name := range fields[1:] {
+			name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+			if !slices.Contains(resolver[name], addr) {
+				resolver[name] = append(resolver[name], addr)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hosts: %w", err)
+	}
+
+	return resolver, nil
+}
+
+// ResolveAll expands host addresses into IP endpoints. IP addresses are used as is,
+// and named service ports are resolved to numbers. Duplicate endpoints are dropped.
+// It returns the endpoints which could be resolved, along with errors for the others.
+func ResolveAll(ctx context.Context, resolver Resolver, hostPorts []HostPort) ([]netip.AddrPort, error) {
+	endpoints := make([]netip.AddrPort, 0, len(hostPorts))
+
+	var errs []error
+
+	for _, hp := range hostPorts {
+		resolved, err := resolveHostPort(ctx, resolver, hp)
+		if err != nil {
+			errs = append(errs, &AddressError{Address: hp.String(), Err: err})
+
+			continue
+		}
+
+		for _, endpoint := range resolved {
+			if !slices.Contains(endpoints, endpoint) {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+
+	return endpoints, errors.Join(errs...)
+}
+
+func resolveHostPort(ctx context.Context, resolver Resolver, hp HostPort) ([]netip.AddrPort, error) {
+	if hp.Host == "" {
+		return nil, fmt.Errorf("%w: empty host", ErrInvalidHost)
+	}
+
+	port, err := resolvePort(hp.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	// resolvePort guarantees a number in port range
+	n, _ := strconv.ParseUint(port, 10, 16)
+
+	if addr, err := netip.ParseAddr(hp.Host); err == nil {
+		return []netip.AddrPort{netip.AddrPortFrom(addr, uint16(n))}, nil
+	}
+
+	addrs, err := resolver.Resolve(ctx, hp.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]netip.AddrPort, len(addrs))
+
+	for i, addr := range addrs {
+		endpoints[i] = netip.AddrPortFrom(addr, uint16(n))
+	}
+
+	return endpoints, nil
+}
@@ -0,0 +1,179 @@
+package hostutil
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingResolver struct {
+	calls int
+	next  Resolver
+}
+
+func (r *countingResolver) Resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	r.calls++
+
+	return r.next.Resolve(ctx, host)
+}
+
+func TestNetResolver(t *testing.T) {
+	t.Parallel()
+
+	addrs, err := NewNetResolver(nil).Resolve(t.Context(), "127.0.0.1")
+
+	require.NoError(t, err)
+	require.Equal(t, []netip.Addr{netip.MustParseAddr("127.0.0.1")}, addrs)
+}
+
+func TestCachingResolver(t *testing.T) {
+	t.Parallel()
+
+	counter := &countingResolver{next: StaticResolver{
+		"api.example.com": {netip.MustParseAddr("10.0.0.1")},
+	}}
+
+	now := time.Unix(0, 0)
+
+	resolver := NewCachingResolver(counter, time.Minute, time.Second)
+	resolver.now = func() time.Time { return now }
+
+	for range 3 {
+		addrs, err := resolver.Resolve(t.Context(), "API.example.com")
+
+		require.NoError(t, err)
+		require.Equal(t, []netip.Addr{netip.MustParseAddr("10.0.0.1")}, addrs)
+	}
+
+	require.Equal(t, 1, counter.calls)
+
+	// negative caching
+	for range 3 {
+		_, err := resolver.Resolve(t.Context(), "missing.example.com")
+
+		var dnsErr *net.DNSError
+
+		require.ErrorAs(t, err, &dnsErr)
+		require.True(t, dnsErr.IsNotFound)
+	}
+
+	require.Equal(t, 2, counter.calls)
+
+	// negative entries expire sooner
+	now = now.Add(2 * time.Second)
+
+	_, _ = resolver.Resolve(t.Context(), "api.example.com")
+	_, _ = resolver.Resolve(t.Context(), "missing.example.com")
+
+	require.Equal(t, 3, counter.calls)
+
+	now = now.Add(time.Minute)
+
+	_, _ = resolver.Resolve(t.Context(), "api.example.com")
+
+	require.Equal(t, 4, counter.calls)
+
+	resolver.Flush()
+
+	_, _ = resolver.Resolve(t.Context(), "api.example.com")
+
+	require.Equal(t, 5, counter.calls)
+}
+
+func TestCachingResolver_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	resolver := NewCachingResolver(resolverFunc(func(ctx context.Context, _ string) ([]netip.Addr, error) {
+		calls++
+
+		return nil, ctx.Err()
+	}), time.Minute, time.Minute)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := resolver.Resolve(ctx, "host")
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = resolver.Resolve(t.Context(), "host")
+	require.NoError(t, err)
+
+	require.Equal(t, 2, calls)
+}
+
+type resolverFunc func(ctx context.Context, host string) ([]netip.Addr, error)
+
+func (f resolverFunc) Resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	return f(ctx, host)
+}
+
+func TestParseHosts(t *testing.T) {
+	t.Parallel()
+
+	resolver, err := ParseHosts(strings.NewReader(`
+# comment
+127.0.0.1   localhost
+::1         localhost ip6-localhost # trailing comment
+10.0.0.1    API.example.com. api
+`))
+
+	require.NoError(t, err)
+	require.Equal(t, StaticResolver{
+		"localhost":       {netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("::1")},
+		"ip6-localhost":   {netip.MustParseAddr("::1")},
+		"api.example.com": {netip.MustParseAddr("10.0.0.1")},
+		"api":             {netip.MustParseAddr("10.0.0.1")},
+	}, resolver)
+
+	_, err = ParseHosts(strings.NewReader("not-an-ip host"))
+	require.ErrorContains(t, err, "line 1")
+}
+
+func TestResolveAll(t *testing.T) {
+	t.Parallel()
+
+	resolver := StaticResolver{
+		"api": {netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.0.0.2")},
+	}
+
+	endpoints, err := ResolveAll(t.Context(), resolver, []HostPort{
+		{"api", "http"},
+		{"10.0.0.1", "80"},
+		{"fe80::1%eth0", "443"},
+		{"api", "8080"},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, []netip.AddrPort{
+		netip.MustParseAddrPort("10.0.0.1:80"),
+		netip.MustParseAddrPort("10.0.0.2:80"),
+		netip.MustParseAddrPort("[fe80::1%eth0]:443"),
+		netip.MustParseAddrPort("10.0.0.1:8080"),
+		netip.MustParseAddrPort("10.0.0.2:8080"),
+	}, endpoints)
+}
+
+func TestResolveAll_Errors(t *testing.T) {
+	t.Parallel()
+
+	endpoints, err := ResolveAll(t.Context(), StaticResolver{}, []HostPort{
+		{"10.0.0.1", "80"},
+		{"missing", "80"},
+		{"", "80"},
+		{"10.0.0.1", "99999"},
+	})
+
+	require.Equal(t, []netip.AddrPort{netip.MustParseAddrPort("10.0.0.1:80")}, endpoints)
+	require.ErrorIs(t, err, ErrInvalidHost)
+	require.ErrorIs(t, err, ErrPortRange)
+
+	var dnsErr *net.DNSError
+
+	require.ErrorAs(t, err, &dnsErr)
+}
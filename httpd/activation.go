@@ -0,0 +1,183 @@
+package httpd
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// defaultFDName is the name systemd gives to sockets without FileDescriptorName.
+const defaultFDName = "unknown"
+
+// ErrNoActivatedListener is returned when no listener with the configured name was passed by socket activation.
+var ErrNoActivatedListener = errors.New("no activated listener")
+
+//nolint:gochecknoglobals // passed file descriptors can be taken over only once per process
+var activation struct {
+	once      sync.Once
+	mu        sync.Mutex
+	activated bool
+	handoff   bool
+	readyFD   string
+	listeners map[string][]net.Listener
+	err       error
+}
+
+// loadActivation takes over the passed file descriptors and consumes the environment variables.
+func loadActivation() {
+	activation.once.Do(func() {
+		activation.handoff = os.Getenv(handoffPIDEnv) == strconv.Itoa(os.Getppid())
+		activation.listeners, activation.err = listenFDs(os.Getenv, os.Getpid(), os.Getppid())
+		activation.activated = activation.listeners != nil || activation.err != nil
+
+		if activation.handoff {
+			activation.readyFD = os.Getenv(handoffReadyFDEnv)
+		}
+
+		for _, key := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES", handoffPIDEnv, handoffReadyFDEnv} {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+// ActivatedListeners returns the listeners passed by systemd socket activation, i.e. with the LISTEN_FDS,
+// LISTEN_PID and LISTEN_FDNAMES environment variables, keyed by their names. The variables are consumed
+// on the first call, so they are not inherited by child processes. Listeners returned by this function,
+// or taken by a server with Config.ListenerName, are not returned again.
+func ActivatedListeners() (map[string][]net.Listener, error) {
+	loadActivation()
+
+	activation.mu.Lock()
+	defer activation.mu.Unlock()
+
+	listeners := activation.listeners
+	activation.listeners = map[string][]net.Listener{}
+
+	if !activation.activated {
+		listeners = nil
+	}
+
+	return listeners, activation.err
+}
+
+// activatedListeners takes the activated listeners with the name.
+// It returns false if the process was not socket activated.
+func activatedListeners(name string) ([]net.Listener, bool, error) {
+	loadActivation()
+
+	activation.mu.Lock()
+	defer activation.mu.Unlock()
+
+	if !activation.activated {
+		return nil, false, nil
+	}
+
+	if activation.err != nil {
+		return nil, true, activation.err
+	}
+
+	listeners, ok := activation.listeners[name]
+	if !ok {
+		return nil, true, errors.Wrapf(ErrNoActivatedListener, "name %q", name)
+	}
+
+	delete(activation.listeners, name)
+
+	return listeners, true, nil
+}
+
+// listenFDs converts the passed file descriptors to listeners. It returns nil if the descriptors
+// were passed neither to the process with the pid by systemd, nor by its parent during an upgrade.
+func listenFDs(getenv func(string) string, pid, ppid int) (map[string][]net.Listener, error) {
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) && getenv(handoffPIDEnv) != strconv.Itoa(ppid) {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || count < 0 {
+		return nil, errors.Errorf("invalid LISTEN_FDS %q", getenv("LISTEN_FDS"))
+	}
+
+	var names []string
+
+	if fdNames := getenv("LISTEN_FDNAMES"); fdNames != "" {
+		names = strings.Split(fdNames, ":")
+	}
+
+	listeners := make(map[string][]net.Listener, count)
+
+	for i := range count {
+		name := defaultFDName
+
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		file := os.NewFile(uintptr(listenFDsStart+i), name)
+
+		// FileListener duplicates the descriptor, so the original one is closed
+		ln, err := net.FileListener(file)
+
+		_ = file.Close()
+
+		if err != nil {
+			for _, lns := range listeners {
+				for _, l := range lns {
+					_ = l.Close()
+				}
+			}
+
+			return nil, errors.Wrapf(err, "failed to use activated socket %q", name)
+		}
+
+		listeners[name] = append(listeners[name], ln)
+	}
+
+	return listeners, nil
+}
+
+// inheritedListeners takes the listeners passed to the process for the server. Listeners are matched by
+// the configured listener name, or after an upgrade, by the interface and port the parent was configured with.
+func inheritedListeners(cfg Config) ([]net.Listener, error) {
+	if cfg.ListenerName != "" {
+		listeners, _, err := activatedListeners(cfg.ListenerName)
+
+		return listeners, err
+	}
+
+	loadActivation()
+
+	if !activation.handoff {
+		return nil, nil
+	}
+
+	listeners, _, err := activatedListeners(listenerName(cfg))
+	if errors.Is(err, ErrNoActivatedListener) {
+		// the binding changed in the new version
+		return nil, nil
+	}
+
+	return listeners, err
+}
+
+// listenerName returns the name of the server listeners when they are passed to another process.
+// The name is escaped, as colons separate the names in LISTEN_FDNAMES.
+func listenerName(cfg Config) string {
+	if cfg.ListenerName != "" {
+		return cfg.ListenerName
+	}
+
+	if cfg.Socket != "" {
+		return url.QueryEscape(unixScheme + cfg.Socket)
+	}
+
+	return url.QueryEscape(net.JoinHostPort(cfg.Interface, cfg.Port))
+}
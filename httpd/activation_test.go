@@ -0,0 +1,101 @@
+package httpd
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("socket activation is not supported on windows")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	require.NoError(t, err)
+
+	file, err := ln.(*net.TCPListener).File()
+
+	require.NoError(t, err)
+
+	// LISTEN_PID has to match the pid of the test binary, which replaces the shell
+	cmd := exec.Command("sh", "-c", `LISTEN_PID=$$ exec "$0" "$@"`, os.Args[0], "-test.run=^TestActivationHelper$", "-test.v")
+	cmd.Env = append(os.Environ(),
+		"LISTEN_FDS=1",
+		"LISTEN_FDNAMES=api",
+		"HTTPD_ACTIVATION_HELPER=1",
+		"HTTPD_ACTIVATION_ADDR="+ln.Addr().String(),
+	)
+	cmd.ExtraFiles = []*os.File{file}
+
+	out, err := cmd.CombinedOutput()
+
+	require.NoError(t, err, string(out))
+	require.Contains(t, string(out), "PASS: TestActivationHelper")
+	require.NoError(t, file.Close())
+	require.NoError(t, ln.Close())
+}
+
+// TestActivationHelper runs in the child process started by TestActivation.
+func TestActivationHelper(t *testing.T) {
+	if os.Getenv("HTTPD_ACTIVATION_HELPER") != "1" {
+		t.Skip("helper process only")
+	}
+
+	ts, err := NewServer(Config{ListenerName: "api", Interface: "198.51.100.0/24"}, acceptHandler)
+
+	require.NoError(t, err)
+	require.Equal(t, os.Getenv("HTTPD_ACTIVATION_ADDR"), ts.Address())
+	require.Empty(t, os.Getenv("LISTEN_FDS"))
+
+	go func() { _ = ts.Run() }()
+
+	rsp, err := http.Get("http://" + ts.Address())
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, rsp.StatusCode)
+	require.NoError(t, rsp.Body.Close())
+
+	// the listener is taken by the first server
+	_, err = NewServer(Config{ListenerName: "api"}, acceptHandler)
+
+	require.ErrorIs(t, err, ErrNoActivatedListener)
+	require.NoError(t, ts.Close())
+}
+
+func TestListenFDs(t *testing.T) {
+	env := map[string]string{"LISTEN_PID": "1", "LISTEN_FDS": "1"}
+	getenv := func(key string) string { return env[key] }
+
+	listeners, err := listenFDs(getenv, 2, 0)
+
+	require.NoError(t, err)
+	require.Nil(t, listeners)
+
+	env["LISTEN_FDS"] = "many"
+
+	_, err = listenFDs(getenv, 1, 0)
+	require.Error(t, err)
+
+	env["LISTEN_FDS"] = "0"
+
+	listeners, err = listenFDs(getenv, 1, 0)
+
+	require.NoError(t, err)
+	require.Empty(t, listeners)
+	require.NotNil(t, listeners)
+
+	// handed off by the parent
+	env = map[string]string{handoffPIDEnv: "7", "LISTEN_FDS": "0"}
+
+	listeners, err = listenFDs(getenv, 1, 7)
+
+	require.NoError(t, err)
+	require.NotNil(t, listeners)
+}
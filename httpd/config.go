@@ -1,7 +1,135 @@
 package httpd
 
+import (
+	"github.com/exopulse/go-kit/hostutil"
+	"github.com/exopulse/go-kit/timex"
+)
+
 // Config contains server setup.
 type Config struct {
+	// Interface is a bind specification, see hostutil.ResolveBindAddresses.
+	// It can be a host, an interface name, a CIDR or one of "loopback", "private", "public" and "all".
+	// Empty interface binds to all addresses.
 	Interface string `env:"HTTPD_INTERFACE"`
 	Port      string `env:"HTTPD_PORT"`
+
+	// Socket is the path of a unix socket. If set, the server listens on the socket instead of the interface
+	// and port. A stale socket file left by a process which did not stop cleanly is removed.
+	Socket string `env:"HTTPD_SOCKET"`
+
+	// SocketMode is the octal file mode of the socket, e.g. "0660". Empty keeps the mode given by umask.
+	SocketMode string `env:"HTTPD_SOCKET_MODE"`
+
+	// SocketGroup is the name or ID of the group owning the socket. Empty keeps the process group.
+	SocketGroup string `env:"HTTPD_SOCKET_GROUP"`
+
+	// ListenerName selects the listeners passed by systemd socket activation, by their FileDescriptorName.
+	// Sockets without a name are named "unknown". If the process was not socket activated,
+	// the server binds to the interface and port.
+	ListenerName string `env:"HTTPD_LISTENER_NAME"`
+
+	// Timeouts of the server. Zero means the default, and negative disables the timeout.
+	// Handlers can override the read and write timeouts with SetReadTimeout and SetWriteTimeout.
+	ReadHeaderTimeout timex.Duration `env:"HTTPD_READ_HEADER_TIMEOUT"`
+	ReadTimeout       timex.Duration `env:"HTTPD_READ_TIMEOUT"`
+	WriteTimeout      timex.Duration `env:"HTTPD_WRITE_TIMEOUT"`
+	IdleTimeout       timex.Duration `env:"HTTPD_IDLE_TIMEOUT"`
+
+	// MaxHeaderBytes limits the size of request headers. Zero means http.DefaultMaxHeaderBytes.
+	MaxHeaderBytes int `env:"HTTPD_MAX_HEADER_BYTES"`
+
+	// MaxBodyBytes limits the size of request bodies. Zero means no limit.
+	// Reading past the limit fails with *http.MaxBytesError.
+	MaxBodyBytes int64 `env:"HTTPD_MAX_BODY_BYTES"`
+
+	// MaxConnections limits open connections. When reached, new connections wait in the listen backlog.
+	// Zero means no limit.
+	MaxConnections int `env:"HTTPD_MAX_CONNECTIONS"`
+
+	// MaxConnectionsPerIP limits open connections of a single client IP. Connections over the limit
+	// are closed at once. Zero means no limit. With the PROXY protocol, the limit applies to the
+	// client address sent by the load balancer, once the header is read.
+	MaxConnectionsPerIP int `env:"HTTPD_MAX_CONNECTIONS_PER_IP"`
+
+	// MaxInFlight limits requests handled at once. Requests over the limit wait in a queue of MaxQueue
+	// requests for up to QueueTimeout, and are rejected with 503 Service Unavailable and the Retry-After
+	// header set to RetryAfter when the queue is full or the wait times out. Zero means no limit.
+	// Zero QueueTimeout and RetryAfter mean one second, and negative QueueTimeout waits as long as the client.
+	MaxInFlight  int            `env:"HTTPD_MAX_IN_FLIGHT"`
+	MaxQueue     int            `env:"HTTPD_MAX_QUEUE"`
+	QueueTimeout timex.Duration `env:"HTTPD_QUEUE_TIMEOUT"`
+	RetryAfter   timex.Duration `env:"HTTPD_RETRY_AFTER"`
+
+	// DisableKeepAlives closes HTTP/1.1 connections after each response.
+	DisableKeepAlives bool `env:"HTTPD_DISABLE_KEEP_ALIVES"`
+
+	// PreStopDelay is the time between marking the server not ready and unbinding it during graceful
+	// shutdown, so load balancers can deregister it. Zero means no delay.
+	PreStopDelay timex.Duration `env:"HTTPD_PRE_STOP_DELAY"`
+
+	// ShutdownTimeout limits draining of in-flight requests during graceful shutdown.
+	// Remaining connections are closed forcibly. Zero means the default of 15 seconds.
+	ShutdownTimeout timex.Duration `env:"HTTPD_SHUTDOWN_TIMEOUT"`
+
+	TLS   TLSConfig   `envPrefix:"HTTPD_TLS_"`
+	HTTP2 HTTP2Config `envPrefix:"HTTPD_HTTP2_"`
+	Proxy ProxyConfig `envPrefix:"HTTPD_PROXY_"`
+}
+
+// TLSConfig contains TLS setup. TLS is enabled when the certificate file is set.
+type TLSConfig struct {
+	CertFile string `env:"CERT_FILE"`
+	KeyFile  string `env:"KEY_FILE"`
+
+	// MinVersion is the minimum TLS version, "1.2" (default) or "1.3".
+	MinVersion string `env:"MIN_VERSION"`
+
+	// CipherPolicy selects TLS 1.2 cipher suites: "default" uses Go defaults,
+	// "modern" allows only ECDHE key exchange with AEAD ciphers.
+	CipherPolicy string `env:"CIPHER_POLICY"`
+
+	// ClientCAFile enables mutual TLS, verifying client certificates against the CA bundle.
+	ClientCAFile string `env:"CLIENT_CA_FILE"`
+
+	// ClientAuth is "require" (default) or "optional". Optional verification accepts clients without
+	// a certificate, but verifies the certificate if one is sent.
+	ClientAuth string `env:"CLIENT_AUTH"`
+
+	// ReloadInterval is the interval of checking the files for changes. Certificates are also
+	// reloaded on SIGHUP. Zero means the default of 30 seconds, and negative disables polling.
+	ReloadInterval timex.Duration `env:"RELOAD_INTERVAL"`
+}
+
+// Enabled reports whether TLS is configured.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// HTTP2Config contains HTTP/2 setup. HTTP/2 is always enabled with TLS.
+type HTTP2Config struct {
+	// H2C enables HTTP/2 without TLS, both with prior knowledge and with the upgrade from HTTP/1.1.
+	H2C bool `env:"H2C"`
+
+	// MaxConcurrentStreams limits concurrent requests on a connection. Zero means the Go default.
+	MaxConcurrentStreams int `env:"MAX_CONCURRENT_STREAMS"`
+
+	// MaxReadFrameSize is the largest frame the server accepts, between 16 KiB and 16 MiB.
+	// Zero or a value out of range means the Go default of 1 MiB.
+	MaxReadFrameSize int `env:"MAX_READ_FRAME_SIZE"`
+}
+
+// ProxyConfig contains PROXY protocol setup.
+type ProxyConfig struct {
+	// Enabled enables decoding of PROXY protocol v1 and v2 headers sent by TCP load balancers.
+	// The client address from the header is returned by http.Request.RemoteAddr, and the header
+	// by ProxyHeaderFromContext. Connections without a valid header are closed.
+	Enabled bool `env:"ENABLED"`
+
+	// TrustedSources are the load balancer addresses allowed to send the header, e.g. "10.0.0.0/8".
+	// Connections from other addresses are served as is. It is required when the protocol is enabled.
+	TrustedSources *hostutil.IPSet `env:"TRUSTED_SOURCES"`
+
+	// HeaderTimeout limits reading the header. Zero means the default of 5 seconds, and negative
+	// disables the timeout.
+	HeaderTimeout timex.Duration `env:"HEADER_TIMEOUT"`
 }
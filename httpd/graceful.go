@@ -0,0 +1,124 @@
+package httpd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/exopulse/go-kit/slog"
+	"github.com/pkg/errors"
+)
+
+// RunGracefully runs the server until the context is done or SIGINT or SIGTERM arrives, and then shuts it down
+// in stages: the server is marked not ready, the pre-stop delay passes, the listeners are unbound, in-flight
+// requests are drained within the shutdown timeout, and remaining connections are closed forcibly.
+// On SIGUSR2, the listeners are handed off to a new instance of the executable with Upgrade, and the server
+// is shut down without the pre-stop delay; if the upgrade fails, the server keeps running.
+// It returns an error if the server fails, or if the connections had to be closed forcibly.
+func RunGracefully(ctx context.Context, s *Server) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	upgradeCh, stopUpgrade := notifyUpgrade()
+	defer stopUpgrade()
+
+	runCh := make(chan error, 1)
+
+	go func() { runCh <- s.Run() }()
+
+	slog.Info().Strs("addresses", s.Addresses()).Msg("server started")
+
+	if err := NotifyReady(); err != nil {
+		slog.Warn().Err(err).Msg("failed to notify parent process")
+	}
+
+	handedOff := false
+
+wait:
+	for {
+		select {
+		case err := <-runCh:
+			return err
+		case <-ctx.Done():
+			slog.Info().Str("cause", context.Cause(ctx).Error()).Msg("server shutdown started")
+
+			break wait
+		case <-upgradeCh:
+			if err := Upgrade(ctx, s); err != nil {
+				slog.Error().Err(err).Msg("upgrade failed")
+
+				continue
+			}
+
+			handedOff = true
+
+			break wait
+		}
+	}
+
+	// restore the default signal behavior, so another signal terminates the process
+	stop()
+
+	err := s.shutdown(handedOff)
+
+	return errors.Wrap(joinErrors(err, <-runCh), "graceful shutdown failed")
+}
+
+// shutdown runs the shutdown stages. The pre-stop delay is skipped if the listeners were handed off.
+func (s *Server) shutdown(handedOff bool) error {
+	s.draining.Store(true)
+
+	slog.Info().Msg("server marked not ready")
+
+	if s.preStopDelay > 0 && !handedOff {
+		slog.Info().Dur("delay", s.preStopDelay).Msg("waiting before unbinding server")
+
+		time.Sleep(s.preStopDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	return s.unbindAndStop(ctx)
+}
+
+// unbindAndStop unbinds the server and drains connections until the context is done,
+// when the remaining connections are closed forcibly.
+func (s *Server) unbindAndStop(ctx context.Context) error {
+	if err := s.Unbind(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+
+	slog.Info().Strs("addresses", s.Addresses()).Msg("server unbound, draining connections")
+
+	err := s.Stop(ctx)
+
+	switch {
+	case err == nil:
+		slog.Info().Strs("addresses", s.Addresses()).Msg("server stopped")
+
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.Warn().Strs("addresses", s.Addresses()).Msg("server drain timed out, closing connections")
+
+		return joinErrors(err, s.Close())
+	default:
+		return err
+	}
+}
+
+// joinErrors combines two errors, any of which can be nil.
+func joinErrors(first, second error) error {
+	switch {
+	case first == nil:
+		return second
+	case second == nil:
+		return first
+	default:
+		return fmt.Errorf("%w; %w", first, second)
+	}
+}
@@ -0,0 +1,135 @@
+package httpd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/timex"
+	"github.com/stretchr/testify/require"
+)
+
+func waitReady(t *testing.T, ts *Server) {
+	t.Helper()
+
+	require.Eventually(t, ts.Ready, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRunGracefully(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	ts, err := NewServer(Config{
+		Interface:    "127.0.0.1",
+		PreStopDelay: timex.Duration(100 * time.Millisecond),
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	doneCh := make(chan error, 1)
+
+	go func() { doneCh <- RunGracefully(ctx, ts) }()
+
+	waitReady(t, ts)
+
+	rspCh := make(chan int, 1)
+
+	go func() {
+		rsp, err := http.Get("http://" + ts.Address() + "/slow")
+		if err != nil {
+			rspCh <- 0
+
+			return
+		}
+
+		_ = rsp.Body.Close()
+		rspCh <- rsp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	require.Eventually(t, func() bool { return !ts.Ready() }, time.Second, 10*time.Millisecond)
+
+	// still bound during the pre-stop delay
+	rsp, err := http.Get("http://" + ts.Address())
+
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+
+	close(release)
+
+	require.Equal(t, http.StatusAccepted, <-rspCh)
+	require.NoError(t, <-doneCh)
+}
+
+func TestRunGracefully_ForceClose(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	defer close(release)
+
+	ts, err := NewServer(Config{
+		Interface:       "127.0.0.1",
+		ShutdownTimeout: timex.Duration(100 * time.Millisecond),
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	doneCh := make(chan error, 1)
+
+	go func() { doneCh <- RunGracefully(ctx, ts) }()
+
+	waitReady(t, ts)
+
+	go func() { _, _ = http.Get("http://" + ts.Address()) }() //nolint:bodyclose // the request never completes
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-doneCh:
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for forced shutdown")
+	}
+}
+
+func TestRunGracefully_Signal(t *testing.T) {
+	ts, err := NewServer(Config{Interface: "127.0.0.1"}, http.NotFoundHandler())
+
+	require.NoError(t, err)
+
+	doneCh := make(chan error, 1)
+
+	go func() { doneCh <- RunGracefully(t.Context(), ts) }()
+
+	waitReady(t, ts)
+
+	process, err := os.FindProcess(os.Getpid())
+
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(syscall.SIGTERM))
+
+	select {
+	case err := <-doneCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+}
@@ -0,0 +1,231 @@
+package httpd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/exopulse/go-kit/slog"
+	"github.com/pkg/errors"
+)
+
+// ComponentError is an error of a named Group component.
+type ComponentError struct {
+	Component string
+	Err       error
+}
+
+// Error implements the error interface.
+func (e *ComponentError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Component, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// Group runs servers and background runners together. When any of them exits, or the context is done,
+// or SIGINT or SIGTERM arrives, all of them are shut down. On SIGUSR2, the listeners of the servers are
+// handed off to a new instance of the executable with Upgrade, and the group is shut down.
+type Group struct {
+	shutdownTimeout time.Duration
+	components      []*component
+}
+
+type component struct {
+	name   string
+	server *Server
+	runner func(ctx context.Context) error
+	cancel context.CancelFunc
+
+	done chan struct{}
+	err  error
+}
+
+// NewGroup creates a new Group. The shutdown timeout is shared by all components, and starts after
+// the pre-stop delay of the servers. Zero means the default of 15 seconds.
+func NewGroup(shutdownTimeout time.Duration) *Group {
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 15 * time.Second
+	}
+
+	return &Group{shutdownTimeout: shutdownTimeout}
+}
+
+// AddServer adds a server to the group.
+func (g *Group) AddServer(name string, s *Server) {
+	g.components = append(g.components, &component{name: name, server: s})
+}
+
+// AddRunner adds a background runner to the group. The runner has to return when its context is cancelled.
+func (g *Group) AddRunner(name string, run func(ctx context.Context) error) {
+	g.components = append(g.components, &component{name: name, runner: run})
+}
+
+// Run runs all components, and blocks until they are shut down. Components are shut down in reverse order
+// of adding, so the ones added first (e.g. shared background workers) stop last. Servers are marked not ready
+// at once, and unbound after the longest of their pre-stop delays. The returned error joins a ComponentError
+// for each component which failed, either while running or during shutdown.
+func (g *Group) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	upgradeCh, stopUpgrade := notifyUpgrade()
+	defer stopUpgrade()
+
+	exited := make(chan *component, len(g.components))
+
+	for _, c := range g.components {
+		c.start(exited)
+	}
+
+	if err := NotifyReady(); err != nil {
+		slog.Warn().Err(err).Msg("failed to notify parent process")
+	}
+
+	handedOff := g.wait(ctx, exited, upgradeCh)
+
+	stop()
+
+	g.drain(handedOff)
+
+	// the timeout starts after the pre-stop delay, the same way it does in RunGracefully
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), g.shutdownTimeout)
+	defer cancel()
+
+	errs := make([]error, len(g.components))
+
+	for i := len(g.components) - 1; i >= 0; i-- {
+		errs[i] = g.components[i].stop(shutdownCtx)
+	}
+
+	var err error
+
+	for i, c := range g.components {
+		// runners are expected to return context.Canceled when stopped
+		if runErr := c.result(); runErr != nil && (c.runner == nil || !errors.Is(runErr, context.Canceled)) {
+			errs[i] = joinErrors(runErr, errs[i])
+		}
+
+		if errs[i] != nil {
+			err = joinErrors(err, &ComponentError{Component: c.name, Err: errs[i]})
+		}
+	}
+
+	return err
+}
+
+// wait waits until a component exits, the context is done, or the listeners are handed off to a new instance.
+// It reports whether the listeners were handed off.
+func (g *Group) wait(ctx context.Context, exited <-chan *component, upgradeCh <-chan os.Signal) bool {
+	for {
+		select {
+		case first := <-exited:
+			slog.Info().Str("component", first.name).AnErr("error", first.err).Msg("component exited, shutting down group")
+
+			return false
+		case <-ctx.Done():
+			slog.Info().Str("cause", context.Cause(ctx).Error()).Msg("group shutdown started")
+
+			return false
+		case <-upgradeCh:
+			if err := Upgrade(ctx, g.servers()...); err != nil {
+				slog.Error().Err(err).Msg("upgrade failed")
+
+				continue
+			}
+
+			return true
+		}
+	}
+}
+
+func (g *Group) servers() []*Server {
+	var servers []*Server
+
+	for _, c := range g.components {
+		if c.server != nil {
+			servers = append(servers, c.server)
+		}
+	}
+
+	return servers
+}
+
+// drain marks all servers not ready, and waits for the longest pre-stop delay,
+// unless the listeners were handed off.
+func (g *Group) drain(handedOff bool) {
+	var delay time.Duration
+
+	for _, c := range g.components {
+		if c.server != nil {
+			c.server.draining.Store(true)
+
+			delay = max(delay, c.server.preStopDelay)
+		}
+	}
+
+	if delay > 0 && !handedOff {
+		slog.Info().Dur("delay", delay).Msg("waiting before unbinding servers")
+
+		time.Sleep(delay)
+	}
+}
+
+func (c *component) start(exited chan<- *component) {
+	c.done = make(chan struct{})
+
+	var ctx context.Context
+
+	if c.runner != nil {
+		ctx, c.cancel = context.WithCancel(context.Background())
+	}
+
+	go func() {
+		defer close(c.done)
+
+		if c.server != nil {
+			c.err = c.server.Run()
+		} else {
+			c.err = c.runner(ctx)
+		}
+
+		exited <- c
+	}()
+}
+
+// result returns the error the component exited with. It returns nil if the component is still running,
+// e.g. when it did not stop in time.
+func (c *component) result() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// stop stops the component within the context deadline, closing server connections forcibly if needed.
+func (c *component) stop(ctx context.Context) error {
+	var err error
+
+	if c.server != nil {
+		err = c.server.unbindAndStop(ctx)
+	} else {
+		c.cancel()
+	}
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return joinErrors(err, errors.Wrap(ctx.Err(), "component did not stop"))
+	}
+
+	slog.Info().Str("component", c.name).Msg("component stopped")
+
+	return err
+}
@@ -0,0 +1,169 @@
+package httpd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/timex"
+	"github.com/stretchr/testify/require"
+)
+
+func newGroupServer(t *testing.T) *Server {
+	t.Helper()
+
+	ts, err := NewServer(Config{Interface: "127.0.0.1"}, acceptHandler)
+
+	require.NoError(t, err)
+
+	return ts
+}
+
+func TestGroup(t *testing.T) {
+	app := newGroupServer(t)
+	admin := newGroupServer(t)
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	runner := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			<-ctx.Done()
+
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+
+			return ctx.Err()
+		}
+	}
+
+	group := NewGroup(time.Second)
+	group.AddRunner("worker", runner("worker"))
+	group.AddServer("app", app)
+	group.AddServer("admin", admin)
+	group.AddRunner("reporter", runner("reporter"))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	doneCh := make(chan error, 1)
+
+	go func() { doneCh <- group.Run(ctx) }()
+
+	waitReady(t, app)
+	waitReady(t, admin)
+
+	for _, ts := range []*Server{app, admin} {
+		rsp, err := http.Get("http://" + ts.Address())
+
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, rsp.StatusCode)
+		require.NoError(t, rsp.Body.Close())
+	}
+
+	cancel()
+
+	require.NoError(t, <-doneCh)
+	require.Equal(t, []string{"reporter", "worker"}, order)
+	require.False(t, app.Ready())
+	require.False(t, admin.Ready())
+}
+
+func TestGroup_ComponentFailure(t *testing.T) {
+	app := newGroupServer(t)
+	errBoom := errors.New("boom")
+
+	group := NewGroup(time.Second)
+	group.AddServer("app", app)
+	group.AddRunner("consumer", func(ctx context.Context) error {
+		return errBoom
+	})
+
+	err := group.Run(t.Context())
+
+	require.ErrorIs(t, err, errBoom)
+
+	var componentErr *ComponentError
+
+	require.ErrorAs(t, err, &componentErr)
+	require.Equal(t, "consumer", componentErr.Component)
+	require.EqualError(t, componentErr, "consumer: boom")
+	require.False(t, app.Ready())
+}
+
+func TestGroup_ShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	group := NewGroup(100 * time.Millisecond)
+	group.AddRunner("stuck", func(ctx context.Context) error {
+		<-release
+
+		return nil
+	})
+	group.AddRunner("late", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(200 * time.Millisecond)
+
+		return errors.New("stopped late")
+	})
+	group.AddRunner("failing", func(ctx context.Context) error {
+		return errors.New("failed to start")
+	})
+
+	err := group.Run(t.Context())
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorContains(t, err, "stuck: component did not stop")
+	require.ErrorContains(t, err, "failing: failed to start")
+	require.ErrorContains(t, err, "late: component did not stop")
+	require.NotContains(t, err.Error(), "stopped late")
+}
+
+func TestGroup_PreStopDelay(t *testing.T) {
+	started := make(chan struct{})
+
+	app, err := NewServer(Config{Interface: "127.0.0.1", PreStopDelay: timex.Duration(200 * time.Millisecond)},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(300 * time.Millisecond)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+
+	require.NoError(t, err)
+
+	// the shutdown timeout is shorter than the delay, but starts after it
+	group := NewGroup(150 * time.Millisecond)
+	group.AddServer("app", app)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	doneCh := make(chan error, 1)
+
+	go func() { doneCh <- group.Run(ctx) }()
+
+	waitReady(t, app)
+
+	statusCh := make(chan int, 1)
+
+	go func() {
+		rsp, err := http.Get("http://" + app.Address())
+		if err != nil {
+			statusCh <- 0
+
+			return
+		}
+
+		_ = rsp.Body.Close()
+		statusCh <- rsp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	require.NoError(t, <-doneCh)
+	require.Equal(t, http.StatusAccepted, <-statusCh)
+}
@@ -0,0 +1,189 @@
+package httpd
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"net/textproto"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const upgradedPollInterval = 10 * time.Millisecond
+
+// configureProtocols applies the HTTP/2 and keep-alive setup to the server. If h2c is enabled,
+// it returns the connections upgraded from HTTP/1.1, which have to be drained separately.
+func configureProtocols(srv *http.Server, cfg Config) *upgradedConns {
+	srv.HTTP2 = &http.HTTP2Config{
+		MaxConcurrentStreams: cfg.HTTP2.MaxConcurrentStreams,
+		MaxReadFrameSize:     cfg.HTTP2.MaxReadFrameSize,
+	}
+
+	if cfg.DisableKeepAlives {
+		srv.SetKeepAlivesEnabled(false)
+	}
+
+	if !cfg.HTTP2.H2C {
+		return nil
+	}
+
+	// the server handles h2c with prior knowledge, and the handler takes over upgraded connections
+	srv.Protocols = new(http.Protocols)
+	srv.Protocols.SetHTTP1(true)
+	srv.Protocols.SetHTTP2(true)
+	srv.Protocols.SetUnencryptedHTTP2(true)
+
+	h2s := &http2.Server{
+		MaxConcurrentStreams: clampUint32(cfg.HTTP2.MaxConcurrentStreams),
+		MaxReadFrameSize:     clampUint32(cfg.HTTP2.MaxReadFrameSize),
+	}
+
+	// ConfigureServer enables graceful shutdown of the connections served by h2s, triggered by
+	// shutting down the server it was configured with. It fails only for TLS cipher suites.
+	h2Shutdown := &http.Server{IdleTimeout: srv.IdleTimeout, ReadTimeout: srv.ReadTimeout}
+	_ = http2.ConfigureServer(h2Shutdown, h2s)
+
+	upgraded := &upgradedConns{
+		next:  srv.Handler,
+		h2c:   h2c.NewHandler(srv.Handler, h2s),
+		conns: make(map[net.Conn]struct{}),
+	}
+
+	srv.Handler = upgraded
+	srv.RegisterOnShutdown(func() {
+		upgraded.shuttingDown.Store(true)
+
+		// sends GOAWAY to the upgraded connections, so they close once their streams are done
+		_ = h2Shutdown.Shutdown(context.Background())
+	})
+
+	return upgraded
+}
+
+// upgradedConns serves h2c upgrades, and tracks the upgraded connections, which are hijacked
+// from the server, so Stop does not wait for them and Close does not close them.
+type upgradedConns struct {
+	next http.Handler
+	h2c  http.Handler
+
+	shuttingDown atomic.Bool
+
+	mu     sync.Mutex
+	active int
+	conns  map[net.Conn]struct{}
+}
+
+// ServeHTTP implements http.Handler. Upgrades are ignored once shutdown starts.
+func (u *upgradedConns) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isH2CUpgrade(r.Header) || u.shuttingDown.Load() {
+		u.next.ServeHTTP(w, r)
+
+		return
+	}
+
+	u.mu.Lock()
+	u.active++
+	u.mu.Unlock()
+
+	tracker := &hijackTracker{ResponseWriter: w, conns: u}
+
+	defer func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		u.active--
+
+		if tracker.conn != nil {
+			delete(u.conns, tracker.conn)
+		}
+	}()
+
+	// the handler returns once the upgraded connection is closed
+	u.h2c.ServeHTTP(tracker, r)
+}
+
+// wait waits until the upgraded connections are closed, or the context is done.
+func (u *upgradedConns) wait(ctx context.Context) error {
+	if u == nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(upgradedPollInterval)
+	defer ticker.Stop()
+
+	for {
+		u.mu.Lock()
+		active := u.active
+		u.mu.Unlock()
+
+		if active == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// close closes the upgraded connections.
+func (u *upgradedConns) close() {
+	if u == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for conn := range u.conns {
+		_ = conn.Close()
+	}
+}
+
+// hijackTracker registers the connection hijacked by the h2c handler.
+type hijackTracker struct {
+	http.ResponseWriter
+
+	conns *upgradedConns
+	conn  net.Conn
+}
+
+// Hijack implements http.Hijacker.
+func (t *hijackTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(t.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck // returned as is by http.Hijacker
+	}
+
+	t.conns.mu.Lock()
+	t.conns.conns[conn] = struct{}{}
+	t.conns.mu.Unlock()
+
+	t.conn = conn
+
+	return conn, rw, nil
+}
+
+// Unwrap returns the original response writer, for http.ResponseController.
+func (t *hijackTracker) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// isH2CUpgrade reports whether the request asks for the upgrade to h2c, the same way the h2c handler does.
+func isH2CUpgrade(h http.Header) bool {
+	return httpguts.HeaderValuesContainsToken(h[textproto.CanonicalMIMEHeaderKey("Upgrade")], "h2c") &&
+		httpguts.HeaderValuesContainsToken(h[textproto.CanonicalMIMEHeaderKey("Connection")], "HTTP2-Settings")
+}
+
+func clampUint32(v int) uint32 {
+	return uint32(min(max(v, 0), math.MaxUint32)) //nolint:gosec // clamped to the range
+}
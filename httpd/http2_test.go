@@ -0,0 +1,223 @@
+package httpd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+var protoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:gochecknoglobals // test handler
+	_, _ = io.WriteString(w, r.Proto)
+})
+
+func h2cClient() *http.Client {
+	var protocols http.Protocols
+
+	protocols.SetUnencryptedHTTP2(true)
+
+	return &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+}
+
+func TestServer_H2C_PriorKnowledge(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", HTTP2: HTTP2Config{H2C: true}}, protoHandler)
+
+	rsp, err := h2cClient().Get("http://" + ts.Address())
+
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(rsp.Body)
+
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	require.Equal(t, "HTTP/2.0", string(body))
+
+	// HTTP/1.1 clients are still served
+	rsp, err = http.Get("http://" + ts.Address())
+
+	require.NoError(t, err)
+
+	body, err = io.ReadAll(rsp.Body)
+
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	require.Equal(t, "HTTP/1.1", string(body))
+}
+
+func TestServer_H2C_Upgrade(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", HTTP2: HTTP2Config{H2C: true}}, protoHandler)
+
+	conn, err := net.Dial("tcp", ts.Address())
+
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\nConnection: Upgrade, HTTP2-Settings\r\n"+
+		"Upgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQAAP__\r\n\r\n")
+
+	require.NoError(t, err)
+
+	rsp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, rsp.StatusCode)
+	require.Equal(t, "h2c", rsp.Header.Get("Upgrade"))
+}
+
+// upgradeH2C upgrades a connection to h2c with a request for the path, and sends the client preface.
+func upgradeH2C(t *testing.T, address, path string) (net.Conn, *http2.Framer) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", address)
+
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	_, err = io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: test\r\nConnection: Upgrade, HTTP2-Settings\r\n"+
+		"Upgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQAAP__\r\n\r\n")
+
+	require.NoError(t, err)
+
+	br := bufio.NewReader(conn)
+	rsp, err := http.ReadResponse(br, nil)
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, rsp.StatusCode)
+
+	_, err = io.WriteString(conn, http2.ClientPreface)
+
+	require.NoError(t, err)
+
+	framer := http2.NewFramer(conn, br)
+
+	require.NoError(t, framer.WriteSettings())
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	return conn, framer
+}
+
+func TestServer_H2C_UpgradeShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+
+	ts, err := NewServer(Config{Interface: "127.0.0.1", HTTP2: HTTP2Config{H2C: true}},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusAccepted)
+		}))
+
+	require.NoError(t, err)
+
+	runCh := make(chan error, 1)
+
+	go func() { runCh <- ts.Run() }()
+
+	_, framer := upgradeH2C(t, ts.Address(), "/")
+
+	<-started
+
+	stopCh := make(chan error, 1)
+
+	go func() { stopCh <- ts.Stop(t.Context()) }()
+
+	// the upgraded connection is told to go away, but its stream is drained
+	for {
+		frame, err := framer.ReadFrame()
+
+		require.NoError(t, err)
+
+		if _, ok := frame.(*http2.GoAwayFrame); ok {
+			break
+		}
+	}
+
+	select {
+	case err := <-stopCh:
+		require.Failf(t, "server stopped with a stream in flight", "error: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	for {
+		frame, err := framer.ReadFrame()
+
+		require.NoError(t, err)
+
+		if headers, ok := frame.(*http2.HeadersFrame); ok {
+			require.EqualValues(t, 1, headers.StreamID)
+
+			break
+		}
+	}
+
+	require.NoError(t, <-stopCh)
+	require.NoError(t, <-runCh)
+}
+
+func TestServer_H2C_UpgradeClose(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	ts, err := NewServer(Config{Interface: "127.0.0.1", HTTP2: HTTP2Config{H2C: true}},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+
+	require.NoError(t, err)
+
+	go func() { _ = ts.Run() }()
+
+	conn, _ := upgradeH2C(t, ts.Address(), "/")
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, ts.Stop(ctx), context.DeadlineExceeded)
+	require.NoError(t, ts.Close())
+
+	// the upgraded connection is closed by the server
+	_, err = io.Copy(io.Discard, conn)
+
+	require.NoError(t, err)
+}
+
+func TestServer_H2C_Disabled(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1"}, protoHandler)
+
+	_, err := h2cClient().Get("http://" + ts.Address())
+
+	require.Error(t, err)
+}
+
+func TestNewServer_HTTP2Settings(t *testing.T) {
+	ts, err := NewServer(Config{
+		Interface: "127.0.0.1",
+		HTTP2:     HTTP2Config{MaxConcurrentStreams: 10, MaxReadFrameSize: 1 << 16},
+	}, protoHandler)
+
+	require.NoError(t, err)
+	require.NoError(t, ts.Unbind())
+
+	require.Equal(t, 10, ts.srv.HTTP2.MaxConcurrentStreams)
+	require.Equal(t, 1<<16, ts.srv.HTTP2.MaxReadFrameSize)
+	require.Nil(t, ts.srv.Protocols)
+}
+
+func TestServer_DisableKeepAlives(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", DisableKeepAlives: true}, protoHandler)
+
+	rsp, err := http.Get("http://" + ts.Address())
+
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	require.True(t, rsp.Close)
+}
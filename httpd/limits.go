@@ -0,0 +1,59 @@
+package httpd
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/exopulse/go-kit/timex"
+	"github.com/pkg/errors"
+)
+
+// timeoutOrDefault returns the configured timeout, def if it is zero, or zero (no timeout) if it is negative.
+func timeoutOrDefault(d timex.Duration, def time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return def
+	case d < 0:
+		return 0
+	default:
+		return d.Duration()
+	}
+}
+
+// limitBody caps the size of request bodies.
+func limitBody(next http.Handler, limit int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetWriteTimeout overrides the server write timeout for the current request, e.g. for long polling
+// or streaming endpoints. The timeout starts now, and zero or negative timeout disables it.
+// The writer has to support http.ResponseController, directly or via Unwrap (as gin.ResponseWriter does).
+func SetWriteTimeout(w http.ResponseWriter, timeout time.Duration) error {
+	if err := http.NewResponseController(w).SetWriteDeadline(deadline(timeout)); err != nil {
+		return errors.Wrap(err, "failed to set write deadline")
+	}
+
+	return nil
+}
+
+// SetReadTimeout overrides the server read timeout for the current request, e.g. for upload endpoints.
+// The timeout starts now, and zero or negative timeout disables it.
+func SetReadTimeout(w http.ResponseWriter, timeout time.Duration) error {
+	if err := http.NewResponseController(w).SetReadDeadline(deadline(timeout)); err != nil {
+		return errors.Wrap(err, "failed to set read deadline")
+	}
+
+	return nil
+}
+
+func deadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(timeout)
+}
@@ -0,0 +1,90 @@
+package httpd
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/timex"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer_Timeouts(t *testing.T) {
+	ts, err := NewServer(Config{
+		Interface:      "127.0.0.1",
+		ReadTimeout:    timex.Duration(time.Minute),
+		WriteTimeout:   -1,
+		MaxHeaderBytes: 4096,
+	}, http.NotFoundHandler())
+
+	require.NoError(t, err)
+	require.NoError(t, ts.Unbind())
+
+	require.Equal(t, readHeaderTimeout, ts.srv.ReadHeaderTimeout)
+	require.Equal(t, time.Minute, ts.srv.ReadTimeout)
+	require.Zero(t, ts.srv.WriteTimeout)
+	require.Equal(t, idleTimeout, ts.srv.IdleTimeout)
+	require.Equal(t, 4096, ts.srv.MaxHeaderBytes)
+}
+
+func TestNewServer_MaxBodyBytes(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", MaxBodyBytes: 8}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+
+		var maxErr *http.MaxBytesError
+
+		if errors.As(err, &maxErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	for body, want := range map[string]int{"small": http.StatusAccepted, "way too large": http.StatusRequestEntityTooLarge} {
+		rsp, err := http.Post("http://"+ts.Address(), "text/plain", strings.NewReader(body))
+
+		require.NoError(t, err)
+		require.Equal(t, want, rsp.StatusCode)
+		require.NoError(t, rsp.Body.Close())
+	}
+}
+
+func TestSetWriteTimeout(t *testing.T) {
+	const delay = 300 * time.Millisecond
+
+	ts := startTestServer(t, Config{
+		Interface:    "127.0.0.1",
+		WriteTimeout: timex.Duration(100 * time.Millisecond),
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			if SetWriteTimeout(w, time.Second) != nil || SetReadTimeout(w, 0) != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+		}
+
+		time.Sleep(delay)
+
+		_, _ = w.Write([]byte("done"))
+	}))
+
+	rsp, err := http.Get("http://" + ts.Address() + "/slow")
+
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(rsp.Body)
+
+	require.NoError(t, err)
+	require.Equal(t, "done", string(body))
+	require.NoError(t, rsp.Body.Close())
+
+	// the default write timeout drops the response
+	_, err = http.Get("http://" + ts.Address() + "/fast")
+	require.Error(t, err)
+}
@@ -0,0 +1,19 @@
+package httpd
+
+import "net"
+
+// Option configures the server.
+type Option func(o *options)
+
+type options struct {
+	listeners []net.Listener
+}
+
+// WithListener makes the server serve on an already bound listener, instead of binding
+// to the configured interface and port. The option can be used multiple times.
+// The server takes ownership of the listener.
+func WithListener(ln net.Listener) Option {
+	return func(o *options) {
+		o.listeners = append(o.listeners, ln)
+	}
+}
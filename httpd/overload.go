@@ -0,0 +1,333 @@
+package httpd
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// errConnectionLimit is returned when reading from a connection closed due to the per-IP limit.
+var errConnectionLimit = errors.New("too many connections from client")
+
+const (
+	queueTimeout = time.Second
+	retryAfter   = time.Second
+)
+
+// Stats contains connection and request counters of a server.
+type Stats struct {
+	// ActiveConnections is the number of open connections.
+	ActiveConnections int64 `json:"activeConnections"`
+
+	// AcceptedConnections is the total number of accepted connections.
+	AcceptedConnections uint64 `json:"acceptedConnections"`
+
+	// RejectedConnections is the total number of connections closed due to the per-IP limit.
+	RejectedConnections uint64 `json:"rejectedConnections"`
+
+	// InFlightRequests is the number of requests being handled.
+	InFlightRequests int64 `json:"inFlightRequests"`
+
+	// QueuedRequests is the number of requests waiting for an in-flight slot.
+	QueuedRequests int64 `json:"queuedRequests"`
+
+	// RejectedRequests is the total number of requests rejected with 503 due to overload.
+	RejectedRequests uint64 `json:"rejectedRequests"`
+}
+
+type counters struct {
+	activeConnections   atomic.Int64
+	acceptedConnections atomic.Uint64
+	rejectedConnections atomic.Uint64
+	inFlightRequests    atomic.Int64
+	queuedRequests      atomic.Int64
+	rejectedRequests    atomic.Uint64
+}
+
+func (c *counters) stats() Stats {
+	return Stats{
+		ActiveConnections:   c.activeConnections.Load(),
+		AcceptedConnections: c.acceptedConnections.Load(),
+		RejectedConnections: c.rejectedConnections.Load(),
+		InFlightRequests:    c.inFlightRequests.Load(),
+		QueuedRequests:      c.queuedRequests.Load(),
+		RejectedRequests:    c.rejectedRequests.Load(),
+	}
+}
+
+// connLimits limits connections across all listeners of a server.
+type connLimits struct {
+	counters *counters
+	slots    chan struct{}
+	perIP    int
+
+	mu    sync.Mutex
+	conns map[netip.Addr]int
+}
+
+func newConnLimits(cfg Config, c *counters) *connLimits {
+	limits := &connLimits{
+		counters: c,
+		perIP:    cfg.MaxConnectionsPerIP,
+		conns:    make(map[netip.Addr]int),
+	}
+
+	if cfg.MaxConnections > 0 {
+		limits.slots = make(chan struct{}, cfg.MaxConnections)
+	}
+
+	return limits
+}
+
+// limitListener counts accepted connections and enforces the limits. When the connection limit
+// is reached, Accept blocks until a connection is closed, so new connections wait in the backlog.
+type limitListener struct {
+	net.Listener
+
+	limits    *connLimits
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *connLimits) wrap(ln net.Listener) net.Listener {
+	return &limitListener{Listener: ln, limits: l, done: make(chan struct{})}
+}
+
+// Accept implements net.Listener.
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		if !l.acquire() {
+			return nil, net.ErrClosed
+		}
+
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			l.release()
+
+			return nil, err //nolint:wrapcheck // returned as is by net.Listener
+		}
+
+		lc := &limitConn{Conn: conn, limits: l.limits, release: l.release}
+
+		// the client address of PROXY protocol connections is known only once the header is read
+		if pc, ok := conn.(*proxyConn); ok {
+			pc.admit = lc.admit
+		} else if !lc.admit(conn.RemoteAddr()) {
+			_ = conn.Close()
+
+			l.release()
+
+			continue
+		}
+
+		l.limits.counters.acceptedConnections.Add(1)
+		l.limits.counters.activeConnections.Add(1)
+
+		return lc, nil
+	}
+}
+
+// Close implements net.Listener. It also releases Accept blocked by the connection limit.
+func (l *limitListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+
+	return l.Listener.Close() //nolint:wrapcheck // returned as is by net.Listener
+}
+
+func (l *limitListener) acquire() bool {
+	if l.limits.slots == nil {
+		return true
+	}
+
+	select {
+	case l.limits.slots <- struct{}{}:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+func (l *limitListener) release() {
+	if l.limits.slots != nil {
+		<-l.limits.slots
+	}
+}
+
+// addIP registers a connection of the client. It returns false if the client reached the limit.
+func (l *connLimits) addIP(addr net.Addr) (netip.Addr, bool) {
+	if l.perIP <= 0 {
+		return netip.Addr{}, true
+	}
+
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		// unix sockets have no client IP
+		return netip.Addr{}, true
+	}
+
+	ip := tcpAddr.AddrPort().Addr().Unmap()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conns[ip] >= l.perIP {
+		l.counters.rejectedConnections.Add(1)
+
+		return netip.Addr{}, false
+	}
+
+	l.conns[ip]++
+
+	return ip, true
+}
+
+func (l *connLimits) removeIP(ip netip.Addr) {
+	if !ip.IsValid() {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conns[ip]--; l.conns[ip] <= 0 {
+		delete(l.conns, ip)
+	}
+}
+
+// limitConn releases its slots when closed.
+type limitConn struct {
+	net.Conn
+
+	limits  *connLimits
+	release func()
+
+	mu     sync.Mutex
+	ip     netip.Addr
+	closed bool
+}
+
+// admit registers the connection of the client address. It returns false if the client reached the limit.
+func (c *limitConn) admit(addr net.Addr) bool {
+	ip, ok := c.limits.addIP(addr)
+	if !ok {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		c.limits.removeIP(ip)
+
+		return true
+	}
+
+	c.ip = ip
+
+	return true
+}
+
+// Close implements net.Conn.
+func (c *limitConn) Close() error {
+	c.mu.Lock()
+
+	if !c.closed {
+		c.closed = true
+
+		c.limits.removeIP(c.ip)
+		c.limits.counters.activeConnections.Add(-1)
+		c.release()
+	}
+
+	c.mu.Unlock()
+
+	return c.Conn.Close() //nolint:wrapcheck // returned as is by net.Conn
+}
+
+// requestLimiter limits in-flight requests. Requests over the limit wait in a bounded queue,
+// and are rejected with 503 Service Unavailable when the queue is full or the wait times out.
+type requestLimiter struct {
+	next       http.Handler
+	counters   *counters
+	slots      chan struct{}
+	queue      chan struct{}
+	timeout    time.Duration
+	retryAfter string
+}
+
+func newRequestLimiter(next http.Handler, cfg Config, c *counters) *requestLimiter {
+	return &requestLimiter{
+		next:       next,
+		counters:   c,
+		slots:      make(chan struct{}, cfg.MaxInFlight),
+		queue:      make(chan struct{}, max(cfg.MaxQueue, 0)),
+		timeout:    timeoutOrDefault(cfg.QueueTimeout, queueTimeout),
+		retryAfter: strconv.Itoa(max(int(timeoutOrDefault(cfg.RetryAfter, retryAfter).Seconds()), 1)),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (l *requestLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !l.acquire(r) {
+		l.counters.rejectedRequests.Add(1)
+
+		w.Header().Set("Retry-After", l.retryAfter)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+
+		return
+	}
+
+	l.counters.inFlightRequests.Add(1)
+
+	defer func() {
+		l.counters.inFlightRequests.Add(-1)
+		<-l.slots
+	}()
+
+	l.next.ServeHTTP(w, r)
+}
+
+func (l *requestLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return false
+	}
+
+	l.counters.queuedRequests.Add(1)
+
+	defer func() {
+		l.counters.queuedRequests.Add(-1)
+		<-l.queue
+	}()
+
+	var timeout <-chan time.Time
+
+	if l.timeout > 0 {
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timeout:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
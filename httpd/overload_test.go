@@ -0,0 +1,179 @@
+package httpd
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/timex"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_MaxInFlight(t *testing.T) {
+	started, release := make(chan struct{}, 1), make(chan struct{})
+
+	ts := startTestServer(t, Config{
+		Interface:    "127.0.0.1",
+		MaxInFlight:  1,
+		MaxQueue:     1,
+		QueueTimeout: -1,
+		RetryAfter:   timex.Duration(5 * time.Second),
+	}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	var wg sync.WaitGroup
+
+	statuses := make(chan int, 2)
+
+	get := func() {
+		rsp, err := http.Get("http://" + ts.Address())
+		if err != nil {
+			statuses <- 0
+
+			return
+		}
+
+		_ = rsp.Body.Close()
+		statuses <- rsp.StatusCode
+	}
+
+	wg.Go(get)
+	<-started
+
+	wg.Go(get)
+	require.Eventually(t, func() bool { return ts.Stats().QueuedRequests == 1 }, time.Second, 10*time.Millisecond)
+	require.EqualValues(t, 1, ts.Stats().InFlightRequests)
+
+	rsp, err := http.Get("http://" + ts.Address())
+
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	require.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	require.Equal(t, "5", rsp.Header.Get("Retry-After"))
+	require.EqualValues(t, 1, ts.Stats().RejectedRequests)
+
+	close(release)
+	<-started
+	wg.Wait()
+
+	require.Equal(t, http.StatusNoContent, <-statuses)
+	require.Equal(t, http.StatusNoContent, <-statuses)
+	require.Zero(t, ts.Stats().InFlightRequests)
+	require.Zero(t, ts.Stats().QueuedRequests)
+}
+
+func TestServer_QueueTimeout(t *testing.T) {
+	release := make(chan struct{})
+
+	ts := startTestServer(t, Config{
+		Interface:    "127.0.0.1",
+		MaxInFlight:  1,
+		MaxQueue:     1,
+		QueueTimeout: timex.Duration(50 * time.Millisecond),
+	}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		if rsp, err := http.Get("http://" + ts.Address()); err == nil {
+			_ = rsp.Body.Close()
+		}
+	})
+
+	require.Eventually(t, func() bool { return ts.Stats().InFlightRequests == 1 }, time.Second, 10*time.Millisecond)
+
+	rsp, err := http.Get("http://" + ts.Address())
+
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	require.Equal(t, http.StatusServiceUnavailable, rsp.StatusCode)
+	require.Equal(t, "1", rsp.Header.Get("Retry-After"))
+
+	close(release)
+	wg.Wait()
+}
+
+func TestServer_MaxConnections(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", MaxConnections: 1}, http.NotFoundHandler())
+
+	first, err := net.Dial("tcp", ts.Address())
+
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return ts.Stats().ActiveConnections == 1 }, time.Second, 10*time.Millisecond)
+
+	// the second connection waits in the backlog until the first one is closed
+	second, err := net.Dial("tcp", ts.Address())
+
+	require.NoError(t, err)
+
+	defer second.Close()
+
+	time.Sleep(50 * time.Millisecond)
+	require.EqualValues(t, 1, ts.Stats().AcceptedConnections)
+
+	require.NoError(t, first.Close())
+	require.Eventually(t, func() bool { return ts.Stats().AcceptedConnections == 2 }, time.Second, 10*time.Millisecond)
+	require.EqualValues(t, 1, ts.Stats().ActiveConnections)
+}
+
+func TestServer_MaxConnections_Unbind(t *testing.T) {
+	ts, err := NewServer(Config{Interface: "127.0.0.1", MaxConnections: 1}, http.NotFoundHandler())
+
+	require.NoError(t, err)
+
+	runCh := make(chan error, 1)
+
+	go func() { runCh <- ts.Run() }()
+
+	conn, err := net.Dial("tcp", ts.Address())
+
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	require.Eventually(t, func() bool { return ts.Stats().ActiveConnections == 1 }, time.Second, 10*time.Millisecond)
+
+	// Run returns even though Accept is blocked by the connection limit
+	require.NoError(t, ts.Unbind())
+	require.NoError(t, <-runCh)
+	require.NoError(t, ts.Close())
+}
+
+func TestServer_MaxConnectionsPerIP(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", MaxConnectionsPerIP: 1}, http.NotFoundHandler())
+
+	first, err := net.Dial("tcp", ts.Address())
+
+	require.NoError(t, err)
+
+	defer first.Close()
+
+	second, err := net.Dial("tcp", ts.Address())
+
+	require.NoError(t, err)
+
+	defer second.Close()
+
+	// the second connection is closed by the server
+	require.NoError(t, second.SetReadDeadline(time.Now().Add(time.Second)))
+
+	_, err = second.Read(make([]byte, 1))
+
+	require.Error(t, err)
+	require.NotErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	stats := ts.Stats()
+
+	require.EqualValues(t, 1, stats.AcceptedConnections)
+	require.EqualValues(t, 1, stats.RejectedConnections)
+	require.EqualValues(t, 1, stats.ActiveConnections)
+}
@@ -0,0 +1,317 @@
+package httpd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/exopulse/go-kit/hostutil"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidProxyHeader is returned when reading from a connection with a missing or malformed PROXY protocol header.
+	ErrInvalidProxyHeader = errors.New("invalid PROXY protocol header")
+
+	// ErrNoTrustedSources is returned by NewServer when the PROXY protocol is enabled without trusted sources.
+	ErrNoTrustedSources = errors.New("PROXY protocol enabled without trusted sources")
+)
+
+const (
+	proxyHeaderTimeout = 5 * time.Second
+
+	// proxyV1MaxLength is the maximum length of a v1 header, including CRLF.
+	proxyV1MaxLength = 107
+
+	proxyV2HeaderLength = 16
+	proxyV2Local        = 0x20
+	proxyV2Proxy        = 0x21
+)
+
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n") //nolint:gochecknoglobals // protocol constant
+
+// ProxyHeader contains the addresses sent by a load balancer in the PROXY protocol header.
+type ProxyHeader struct {
+	// Version is the protocol version, 1 or 2.
+	Version int
+
+	// Source is the address of the client, and Destination is the address the client connected to.
+	// They are nil if the proxy did not forward the addresses, e.g. for its health checks.
+	Source      net.Addr
+	Destination net.Addr
+
+	// Proxy is the address of the load balancer which sent the header.
+	Proxy net.Addr
+}
+
+type proxyConnKey struct{}
+
+// ProxyHeaderFromContext returns the PROXY protocol header of the connection serving the request.
+// It returns false if the connection did not send the header.
+func ProxyHeaderFromContext(ctx context.Context) (*ProxyHeader, bool) {
+	conn, ok := ctx.Value(proxyConnKey{}).(*proxyConn)
+	if !ok || conn.init() != nil {
+		return nil, false
+	}
+
+	return conn.header, true
+}
+
+// proxyConnContext adds PROXY protocol connections to the connection context.
+func proxyConnContext(ctx context.Context, conn net.Conn) context.Context {
+	for {
+		switch c := conn.(type) {
+		case *proxyConn:
+			return context.WithValue(ctx, proxyConnKey{}, c)
+		case *limitConn:
+			conn = c.Conn
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return ctx
+		}
+	}
+}
+
+// proxyListener decodes PROXY protocol headers of connections from trusted sources.
+// Connections from other sources are passed as is.
+type proxyListener struct {
+	net.Listener
+
+	trusted *hostutil.IPSet
+	timeout time.Duration
+}
+
+func newProxyListener(ln net.Listener, cfg ProxyConfig) net.Listener {
+	return &proxyListener{
+		Listener: ln,
+		trusted:  cfg.TrustedSources,
+		timeout:  timeoutOrDefault(cfg.HeaderTimeout, proxyHeaderTimeout),
+	}
+}
+
+// Accept implements net.Listener.
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // returned as is by net.Listener
+	}
+
+	if !l.isTrusted(conn.RemoteAddr()) {
+		return conn, nil
+	}
+
+	return &proxyConn{Conn: conn, timeout: l.timeout}, nil
+}
+
+func (l *proxyListener) isTrusted(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+
+	return ok && l.trusted.Contains(tcpAddr.AddrPort().Addr().Unmap())
+}
+
+// proxyConn reads the PROXY protocol header on first use. Server calls RemoteAddr before reading
+// the request, so the header is read in the goroutine serving the connection, not in Accept.
+type proxyConn struct {
+	net.Conn
+
+	timeout time.Duration
+	reader  *bufio.Reader
+	once    sync.Once
+	header  *ProxyHeader
+	err     error
+
+	// admit applies the per-IP connection limit to the client address, once the header is read.
+	admit func(addr net.Addr) bool
+}
+
+func (c *proxyConn) init() error {
+	c.once.Do(func() {
+		c.reader = bufio.NewReader(c.Conn)
+
+		if c.timeout > 0 {
+			_ = c.SetReadDeadline(time.Now().Add(c.timeout))
+			defer func() { _ = c.SetReadDeadline(time.Time{}) }()
+		}
+
+		c.header, c.err = readProxyHeader(c.reader)
+		if c.err != nil {
+			// close at once, so the server does not respond to the load balancer
+			_ = c.Conn.Close()
+
+			return
+		}
+
+		c.header.Proxy = c.Conn.RemoteAddr()
+
+		client := c.header.Source
+		if client == nil {
+			client = c.header.Proxy
+		}
+
+		if c.admit != nil && !c.admit(client) {
+			c.err = errConnectionLimit
+
+			_ = c.Conn.Close()
+		}
+	})
+
+	return c.err
+}
+
+// Read implements net.Conn.
+func (c *proxyConn) Read(b []byte) (int, error) {
+	if err := c.init(); err != nil {
+		return 0, err
+	}
+
+	return c.reader.Read(b) //nolint:wrapcheck // returned as is by net.Conn
+}
+
+// RemoteAddr implements net.Conn. It returns the client address sent by the proxy, if any.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	if c.init() == nil && c.header.Source != nil {
+		return c.header.Source
+	}
+
+	return c.Conn.RemoteAddr()
+}
+
+// readProxyHeader reads a v1 or v2 PROXY protocol header.
+func readProxyHeader(r *bufio.Reader) (*ProxyHeader, error) {
+	prefix, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProxyHeader, err)
+	}
+
+	switch {
+	case bytes.Equal(prefix, proxyV2Signature):
+		return readProxyHeaderV2(r)
+	case bytes.HasPrefix(prefix, []byte("PROXY ")):
+		return readProxyHeaderV1(r)
+	default:
+		return nil, fmt.Errorf("%w: unknown signature", ErrInvalidProxyHeader)
+	}
+}
+
+// readProxyHeaderV1 reads a header such as "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n".
+func readProxyHeaderV1(r *bufio.Reader) (*ProxyHeader, error) {
+	var line []byte
+
+	for len(line) <= proxyV1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidProxyHeader, err)
+		}
+
+		line = append(line, b)
+
+		if b == '\n' {
+			break
+		}
+	}
+
+	if len(line) > proxyV1MaxLength || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("%w: line too long or not terminated by CRLF", ErrInvalidProxyHeader)
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	header := &ProxyHeader{Version: 1}
+
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return header, nil
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("%w: malformed line %q", ErrInvalidProxyHeader, line)
+	}
+
+	src, err := parseProxyAddr(fields[2], fields[4], fields[1] == "TCP6")
+	if err != nil {
+		return nil, err
+	}
+
+	dst, err := parseProxyAddr(fields[3], fields[5], fields[1] == "TCP6")
+	if err != nil {
+		return nil, err
+	}
+
+	header.Source, header.Destination = src, dst
+
+	return header, nil
+}
+
+func parseProxyAddr(host, port string, ipv6 bool) (net.Addr, error) {
+	ip, err := netip.ParseAddr(host)
+	if err != nil || ip.Is6() != ipv6 {
+		return nil, fmt.Errorf("%w: invalid address %q", ErrInvalidProxyHeader, host)
+	}
+
+	// ports must be decimal numbers without leading zeros
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || strconv.FormatUint(p, 10) != port {
+		return nil, fmt.Errorf("%w: invalid port %q", ErrInvalidProxyHeader, port)
+	}
+
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, uint16(p))), nil
+}
+
+// readProxyHeaderV2 reads a binary header. Type-length-value extensions are skipped.
+func readProxyHeaderV2(r *bufio.Reader) (*ProxyHeader, error) {
+	var fixed [proxyV2HeaderLength]byte
+
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProxyHeader, err)
+	}
+
+	command, family := fixed[12], fixed[13]
+	payload := make([]byte, binary.BigEndian.Uint16(fixed[14:]))
+
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProxyHeader, err)
+	}
+
+	header := &ProxyHeader{Version: 2}
+
+	switch command {
+	case proxyV2Local:
+		return header, nil
+	case proxyV2Proxy:
+	default:
+		return nil, fmt.Errorf("%w: unsupported version and command 0x%02x", ErrInvalidProxyHeader, command)
+	}
+
+	var size int
+
+	// only TCP over IPv4 and IPv6 are used by HTTP, other families are accepted without addresses
+	switch family {
+	case 0x11:
+		size = net.IPv4len
+	case 0x21:
+		size = net.IPv6len
+	default:
+		return header, nil
+	}
+
+	if len(payload) < 2*size+4 {
+		return nil, fmt.Errorf("%w: address block too short", ErrInvalidProxyHeader)
+	}
+
+	src, _ := netip.AddrFromSlice(payload[:size])
+	dst, _ := netip.AddrFromSlice(payload[size : 2*size])
+	ports := payload[2*size:]
+
+	header.Source = net.TCPAddrFromAddrPort(netip.AddrPortFrom(src, binary.BigEndian.Uint16(ports)))
+	header.Destination = net.TCPAddrFromAddrPort(netip.AddrPortFrom(dst, binary.BigEndian.Uint16(ports[2:])))
+
+	return header, nil
+}
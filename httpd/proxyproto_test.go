@@ -0,0 +1,228 @@
+package httpd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/hostutil"
+	"github.com/exopulse/go-kit/timex"
+	"github.com/stretchr/testify/require"
+)
+
+func proxyV2Header(command, family byte, addresses ...byte) string {
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, command, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(addresses)))
+
+	return string(append(header, addresses...))
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	ipv4 := []byte{192, 0, 2, 1, 192, 0, 2, 2, 0xdc, 0x04, 0x01, 0xbb}
+	ipv6 := append(append(make([]byte, 15), 1), append(make([]byte, 15), 2)...)
+	ipv6 = append(ipv6, 0xdc, 0x04, 0x01, 0xbb)
+
+	tests := []struct {
+		name    string
+		input   string
+		version int
+		source  string
+		dest    string
+	}{
+		{"v1 tcp4", "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n", 1, "192.0.2.1:56324", "192.0.2.2:443"},
+		{"v1 tcp6", "PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n", 1, "[2001:db8::1]:56324", "[2001:db8::2]:443"},
+		{"v1 unknown", "PROXY UNKNOWN\r\n", 1, "", ""},
+		{"v2 tcp4", proxyV2Header(proxyV2Proxy, 0x11, ipv4...), 2, "192.0.2.1:56324", "192.0.2.2:443"},
+		{"v2 tcp6", proxyV2Header(proxyV2Proxy, 0x21, ipv6...), 2, "[::1]:56324", "[::2]:443"},
+		{"v2 tlvs", proxyV2Header(proxyV2Proxy, 0x11, append(ipv4, 0x04, 0x00, 0x01, 0x00)...), 2, "192.0.2.1:56324", "192.0.2.2:443"},
+		{"v2 local", proxyV2Header(proxyV2Local, 0x00), 2, "", ""},
+		{"v2 unix", proxyV2Header(proxyV2Proxy, 0x31, make([]byte, 216)...), 2, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.input + "GET"))
+
+			header, err := readProxyHeader(r)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.version, header.Version)
+
+			if tt.source == "" {
+				require.Nil(t, header.Source)
+				require.Nil(t, header.Destination)
+			} else {
+				require.Equal(t, tt.source, header.Source.String())
+				require.Equal(t, tt.dest, header.Destination.String())
+			}
+
+			rest, err := io.ReadAll(r)
+
+			require.NoError(t, err)
+			require.Equal(t, "GET", string(rest))
+		})
+	}
+}
+
+func TestReadProxyHeader_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"GET / HTTP/1.1\r\n\r\n",
+		"PROXY TCP4 192.0.2.1 192.0.2.2 56324\r\n",
+		"PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\n",
+		"PROXY TCP4 2001:db8::1 192.0.2.2 56324 443\r\n",
+		"PROXY TCP4 192.0.2.1 192.0.2.2 056324 443\r\n",
+		"PROXY TCP4 192.0.2.1 192.0.2.2 56324 443" + strings.Repeat(" ", 100) + "\r\n",
+		"PROXY UDP4 192.0.2.1 192.0.2.2 56324 443\r\n",
+		"PROXY TCP4",
+		proxyV2Header(0x22, 0x11),
+		proxyV2Header(proxyV2Proxy, 0x11, 192, 0, 2, 1),
+		proxyV2Header(proxyV2Proxy, 0x11)[:proxyV2HeaderLength-1],
+	} {
+		_, err := readProxyHeader(bufio.NewReader(strings.NewReader(input)))
+
+		require.ErrorIs(t, err, ErrInvalidProxyHeader, input)
+	}
+}
+
+var proxyHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:gochecknoglobals // test handler
+	proxy := "none"
+
+	if header, ok := ProxyHeaderFromContext(r.Context()); ok {
+		proxy = fmt.Sprintf("v%d %v", header.Version, header.Destination)
+	}
+
+	_, _ = fmt.Fprintf(w, "%s %s", r.RemoteAddr, proxy)
+})
+
+// proxyGet sends the PROXY protocol header followed by a request, and returns the response body.
+func proxyGet(t *testing.T, address, header string) (string, error) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", address)
+
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	_, err = io.WriteString(conn, header+"GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+
+	require.NoError(t, err)
+
+	rsp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return "", err
+	}
+
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+
+	require.NoError(t, err)
+
+	return string(body), nil
+}
+
+func TestServer_ProxyProtocol(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", Proxy: ProxyConfig{
+		Enabled:        true,
+		TrustedSources: hostutil.MustParseIPSet("127.0.0.1/32"),
+	}}, proxyHandler)
+
+	body, err := proxyGet(t, ts.Address(), "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n")
+
+	require.NoError(t, err)
+	require.Equal(t, "192.0.2.1:56324 v1 192.0.2.2:443", body)
+
+	body, err = proxyGet(t, ts.Address(), proxyV2Header(proxyV2Local, 0x00))
+
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(body, "127.0.0.1:"), body)
+	require.True(t, strings.HasSuffix(body, " v2 <nil>"), body)
+
+	// connections without the header are closed
+	_, err = proxyGet(t, ts.Address(), "")
+
+	require.Error(t, err)
+}
+
+func TestServer_ProxyProtocol_Untrusted(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", Proxy: ProxyConfig{
+		Enabled:        true,
+		TrustedSources: hostutil.MustParseIPSet("198.51.100.0/24"),
+	}}, proxyHandler)
+
+	body, err := proxyGet(t, ts.Address(), "")
+
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(body, "127.0.0.1:"), body)
+	require.True(t, strings.HasSuffix(body, " none"), body)
+}
+
+func TestServer_ProxyProtocol_HeaderTimeout(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", Proxy: ProxyConfig{
+		Enabled:        true,
+		TrustedSources: hostutil.MustParseIPSet("127.0.0.1/32"),
+		HeaderTimeout:  timex.Duration(50 * time.Millisecond),
+	}}, proxyHandler)
+
+	conn, err := net.Dial("tcp", ts.Address())
+
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	_, err = io.WriteString(conn, "PROXY TCP4")
+
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	// the server closes the connection once the timeout expires
+	_, err = conn.Read(make([]byte, 1))
+
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestNewServer_ProxyProtocol_NoTrustedSources(t *testing.T) {
+	_, err := NewServer(Config{Interface: "127.0.0.1", Proxy: ProxyConfig{Enabled: true}}, proxyHandler)
+
+	require.ErrorIs(t, err, ErrNoTrustedSources)
+}
+
+func TestServer_ProxyProtocol_MaxConnectionsPerIP(t *testing.T) {
+	ts := startTestServer(t, Config{Interface: "127.0.0.1", MaxConnectionsPerIP: 1, Proxy: ProxyConfig{
+		Enabled:        true,
+		TrustedSources: hostutil.MustParseIPSet("127.0.0.1/32"),
+	}}, proxyHandler)
+
+	// connections of different clients from the same load balancer
+	first, err := net.Dial("tcp", ts.Address())
+
+	require.NoError(t, err)
+
+	defer first.Close()
+
+	_, err = io.WriteString(first, "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n")
+
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return ts.Stats().ActiveConnections == 1
+	}, time.Second, 10*time.Millisecond)
+
+	body, err := proxyGet(t, ts.Address(), "PROXY TCP4 192.0.2.3 192.0.2.2 56324 443\r\n")
+
+	require.NoError(t, err)
+	require.Equal(t, "192.0.2.3:56324 v1 192.0.2.2:443", body)
+
+	// another connection of the first client is closed
+	_, err = proxyGet(t, ts.Address(), "PROXY TCP4 192.0.2.1 192.0.2.2 56325 443\r\n")
+
+	require.Error(t, err)
+	require.EqualValues(t, 1, ts.Stats().RejectedConnections)
+}
@@ -2,10 +2,15 @@ package httpd
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/exopulse/go-kit/hostutil"
 	"github.com/pkg/errors"
 )
 
@@ -14,50 +19,229 @@ const (
 	readTimeout       = 15 * time.Second
 	idleTimeout       = 30 * time.Second
 	writeTimeout      = 15 * time.Second
+	shutdownTimeout   = 15 * time.Second
 )
 
 // Server implements an HTTP server.
 type Server struct {
-	srv      *http.Server
-	listener net.Listener
+	srv       *http.Server
+	listeners []net.Listener
+	served    []net.Listener
+	certs     *certReloader
+	name      string
+	counters  *counters
+	upgraded  *upgradedConns
+
+	preStopDelay    time.Duration
+	shutdownTimeout time.Duration
+
+	running  atomic.Bool
+	draining atomic.Bool
 }
 
 // NewServer creates a new server.
-func NewServer(cfg Config, handler http.Handler) (*Server, error) {
+// The interface is resolved with hostutil.ResolveBindAddresses, and the server listens on each
+// resolved address. If the port is not specified, all listeners share the port picked for the first one.
+// If listeners are passed with WithListener, or the process was socket activated and the
+// listener name is configured, the interface and port are ignored.
+// If TLS is configured, the certificates are loaded before binding.
+func NewServer(cfg Config, handler http.Handler, opts ...Option) (*Server, error) {
+	var o options
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if cfg.Proxy.Enabled && cfg.Proxy.TrustedSources.IsEmpty() {
+		return nil, ErrNoTrustedSources
+	}
+
+	var (
+		certs     *certReloader
+		tlsConfig *tls.Config
+	)
+
+	if cfg.TLS.Enabled() {
+		var err error
+
+		if certs, err = newCertReloader(cfg.TLS); err != nil {
+			return nil, err
+		}
+
+		tlsConfig = certs.tlsConfig()
+	}
+
+	listeners, name := o.listeners, ""
+
+	if len(listeners) == 0 {
+		var err error
+
+		if listeners, err = bind(cfg); err != nil {
+			return nil, err
+		}
+
+		name = listenerName(cfg)
+	}
+
+	if cfg.MaxBodyBytes > 0 {
+		handler = limitBody(handler, cfg.MaxBodyBytes)
+	}
+
+	c := &counters{}
+
+	if cfg.MaxInFlight > 0 {
+		handler = newRequestLimiter(handler, cfg, c)
+	}
+
+	limits := newConnLimits(cfg, c)
+	served := make([]net.Listener, len(listeners))
+
+	for i, ln := range listeners {
+		if cfg.Proxy.Enabled {
+			ln = newProxyListener(ln, cfg.Proxy)
+		}
+
+		served[i] = limits.wrap(ln)
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: timeoutOrDefault(cfg.ReadHeaderTimeout, readHeaderTimeout),
+		ReadTimeout:       timeoutOrDefault(cfg.ReadTimeout, readTimeout),
+		WriteTimeout:      timeoutOrDefault(cfg.WriteTimeout, writeTimeout),
+		IdleTimeout:       timeoutOrDefault(cfg.IdleTimeout, idleTimeout),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		TLSConfig:         tlsConfig,
+		ConnContext:       proxyConnContext,
+	}
+
+	upgraded := configureProtocols(srv, cfg)
+
+	return &Server{
+		srv:             srv,
+		listeners:       listeners,
+		served:          served,
+		certs:           certs,
+		name:            name,
+		counters:        c,
+		upgraded:        upgraded,
+		preStopDelay:    max(cfg.PreStopDelay.Duration(), 0),
+		shutdownTimeout: timeoutOrDefault(cfg.ShutdownTimeout, shutdownTimeout),
+	}, nil
+}
+
+func bind(cfg Config) ([]net.Listener, error) {
 	const timeout = 5 * time.Second
 
+	if listeners, err := inheritedListeners(cfg); err != nil || listeners != nil {
+		return listeners, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	var lc net.ListenConfig
+	if cfg.Socket != "" {
+		ln, err := listenUnix(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
 
-	ln, err := lc.Listen(ctx, "tcp", net.JoinHostPort(cfg.Interface, cfg.Port))
+		return []net.Listener{ln}, nil
+	}
+
+	hosts, err := hostutil.ResolveBindAddresses(cfg.Interface)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to bind HTTP server")
+		return nil, errors.Wrap(err, "failed to resolve HTTP server interface")
 	}
 
-	return &Server{
-		srv: &http.Server{
-			Handler:           handler,
-			ReadHeaderTimeout: readHeaderTimeout,
-			ReadTimeout:       readTimeout,
-			WriteTimeout:      writeTimeout,
-			IdleTimeout:       idleTimeout,
-		},
-		listener: ln,
-	}, nil
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+
+	return listen(ctx, hosts, cfg.Port)
 }
 
-// Run runs the server. It blocks until the server is stopped.
+// listen opens a listener on each host. If the listeners fail to bind, the ones already opened are closed.
+func listen(ctx context.Context, hosts []string, port string) ([]net.Listener, error) {
+	var lc net.ListenConfig
+
+	listeners := make([]net.Listener, 0, len(hosts))
+
+	for _, host := range hosts {
+		ln, err := lc.Listen(ctx, "tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+
+			return nil, errors.Wrapf(err, "failed to bind HTTP server to %q", host)
+		}
+
+		if port == "" || port == "0" {
+			if addr, ok := ln.Addr().(*net.TCPAddr); ok {
+				port = strconv.Itoa(addr.Port)
+			}
+		}
+
+		listeners = append(listeners, ln)
+	}
+
+	return listeners, nil
+}
+
+// Run runs the server on all listeners. It blocks until the server is stopped.
+// If serving fails on some listener, the first such error is returned once all listeners are done.
+// With TLS, certificates are reloaded while the server runs.
 func (s *Server) Run() error {
-	// ErrServerClosed is returned when the server is stopped.
-	// ErrClosed is returned when the listener is closed.
-	// We don't want to return an error in these cases.
-	if err := s.srv.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
-		return errors.Wrap(err, "error serving")
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	if s.certs != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go s.certs.watch(ctx)
 	}
 
-	return nil
+	s.running.Store(true)
+	defer s.running.Store(false)
+
+	for _, ln := range s.served {
+		wg.Go(func() {
+			// ErrServerClosed is returned when the server is stopped.
+			// ErrClosed is returned when the listener is closed.
+			// We don't want to return an error in these cases.
+			if err := s.serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+				once.Do(func() { firstErr = errors.Wrap(err, "error serving") })
+			}
+		})
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+func (s *Server) serve(ln net.Listener) error {
+	if s.certs != nil {
+		// certificates are provided by the TLS config
+		return s.srv.ServeTLS(ln, "", "")
+	}
+
+	return s.srv.Serve(ln)
+}
+
+// CertificateExpiry returns the expiry time of the currently loaded TLS certificate.
+// It returns zero time if TLS is not enabled.
+func (s *Server) CertificateExpiry() time.Time {
+	if s.certs == nil {
+		return time.Time{}
+	}
+
+	return s.certs.certificateExpiry()
 }
 
 // Stop stops the server gracefully. It blocks until all connections are closed.
@@ -67,21 +251,62 @@ func (s *Server) Stop(ctx context.Context) error {
 		return errors.Wrap(err, "server shutdown failed")
 	}
 
+	if err := s.upgraded.wait(ctx); err != nil {
+		return errors.Wrap(err, "server shutdown failed")
+	}
+
 	return nil
 }
 
-// Unbind unbinds the server from the listening address.
+// Close closes all listeners and connections immediately, without waiting for in-flight requests.
+func (s *Server) Close() error {
+	s.upgraded.close()
+
+	if err := s.srv.Close(); err != nil {
+		return errors.Wrap(err, "server close failed")
+	}
+
+	return nil
+}
+
+// Ready reports whether the server is running and not shutting down.
+func (s *Server) Ready() bool {
+	return s.running.Load() && !s.draining.Load()
+}
+
+// Unbind unbinds the server from the listening addresses, and removes its unix socket file.
 // Existing connections are not closed. New connections are rejected.
 // It returns an error if the server is already unbound.
 func (s *Server) Unbind() error {
-	if err := s.listener.Close(); err != nil {
-		return errors.Wrap(err, "failed to close listener")
+	var firstErr error
+
+	for _, ln := range s.served {
+		if err := ln.Close(); err != nil && firstErr == nil {
+			firstErr = errors.Wrap(err, "failed to close listener")
+		}
 	}
 
-	return nil
+	return firstErr
 }
 
-// Address returns the server address.
+// Address returns the address of the first listener. Unix socket addresses are returned
+// as "unix://" followed by the socket path, as accepted by hostutil.ParseAddress.
 func (s *Server) Address() string {
-	return s.listener.Addr().String()
+	return listenerAddress(s.listeners[0])
+}
+
+// Stats returns the connection and request counters of the server.
+func (s *Server) Stats() Stats {
+	return s.counters.stats()
+}
+
+// Addresses returns the addresses of all listeners.
+func (s *Server) Addresses() []string {
+	addresses := make([]string, len(s.listeners))
+
+	for i, ln := range s.listeners {
+		addresses[i] = listenerAddress(ln)
+	}
+
+	return addresses
 }
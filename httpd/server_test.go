@@ -2,10 +2,12 @@ package httpd
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/exopulse/go-kit/hostutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -92,3 +94,108 @@ func TestNewServer(t *testing.T) {
 		t.Fatalf("timeout waiting for server to stop (timeout: %s)", timeout)
 	}
 }
+
+func TestNewServer_MultipleListeners(t *testing.T) {
+	hosts, err := hostutil.ResolveBindAddresses(hostutil.BindLoopback)
+	if err != nil || len(hosts) < 2 {
+		t.Skipf("two loopback addresses are required (found %v, err: %v)", hosts, err)
+	}
+
+	ts, err := NewServer(Config{
+		Interface: hostutil.BindLoopback,
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	require.NoError(t, err)
+
+	errorCh := make(chan error, 1)
+
+	go func() { errorCh <- ts.Run() }()
+
+	addresses := ts.Addresses()
+
+	require.Len(t, addresses, len(hosts))
+	require.Equal(t, addresses[0], ts.Address())
+
+	_, port0, _ := net.SplitHostPort(addresses[0])
+	_, port1, _ := net.SplitHostPort(addresses[1])
+
+	require.Equal(t, port0, port1)
+
+	for _, address := range addresses {
+		rsp, err := http.Get("http://" + address)
+
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, rsp.StatusCode)
+		require.NoError(t, rsp.Body.Close())
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, ts.Stop(ctx))
+	require.NoError(t, <-errorCh)
+}
+
+func TestNewServer_BindError(t *testing.T) {
+	_, err := NewServer(Config{Interface: "198.51.100.0/24"}, http.NotFoundHandler())
+
+	require.ErrorIs(t, err, hostutil.ErrNoInterfaceAddresses)
+}
+
+func TestNewServer_WithListener(t *testing.T) {
+	ln, err := hostutil.ListenFree(t.Context(), "tcp", "127.0.0.1")
+
+	require.NoError(t, err)
+
+	ts, err := NewServer(Config{Interface: "198.51.100.0/24"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}), WithListener(ln))
+
+	require.NoError(t, err)
+	require.Equal(t, ln.Addr().String(), ts.Address())
+
+	errorCh := make(chan error, 1)
+
+	go func() { errorCh <- ts.Run() }()
+
+	rsp, err := http.Get("http://" + ts.Address())
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, rsp.StatusCode)
+	require.NoError(t, rsp.Body.Close())
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, ts.Stop(ctx))
+	require.NoError(t, <-errorCh)
+}
+
+func startTestServer(t *testing.T, cfg Config, handler http.Handler) *Server {
+	t.Helper()
+
+	ts, err := NewServer(cfg, handler)
+
+	require.NoError(t, err)
+
+	errorCh := make(chan error, 1)
+
+	go func() { errorCh <- ts.Run() }()
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		require.NoError(t, ts.Stop(ctx))
+		require.NoError(t, <-errorCh)
+	})
+
+	return ts
+}
+
+//nolint:gochecknoglobals // test handler
+var acceptHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusAccepted)
+})
@@ -0,0 +1,218 @@
+package httpd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/exopulse/go-kit/slog"
+	"github.com/pkg/errors"
+)
+
+const defaultReloadInterval = 30 * time.Second
+
+// modernCipherSuites are TLS 1.2 suites with ECDHE key exchange and AEAD ciphers.
+// TLS 1.3 suites are not configurable.
+//
+//nolint:gochecknoglobals // constant list
+var modernCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// certReloader holds the TLS certificate and client CAs, and reloads them when the files change.
+type certReloader struct {
+	cfg  TLSConfig
+	base *tls.Config
+
+	mu       sync.RWMutex
+	current  *tls.Config
+	expiry   time.Time
+	contents [][]byte
+}
+
+func newCertReloader(cfg TLSConfig) (*certReloader, error) {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+
+	switch cfg.MinVersion {
+	case "", "1.2":
+	case "1.3":
+		base.MinVersion = tls.VersionTLS13
+	default:
+		return nil, errors.Errorf("unsupported TLS version %q", cfg.MinVersion)
+	}
+
+	switch cfg.CipherPolicy {
+	case "", "default":
+	case "modern":
+		base.CipherSuites = modernCipherSuites
+	default:
+		return nil, errors.Errorf("unsupported cipher policy %q", cfg.CipherPolicy)
+	}
+
+	if cfg.ClientCAFile != "" {
+		switch cfg.ClientAuth {
+		case "", "require":
+			base.ClientAuth = tls.RequireAndVerifyClientCert
+		case "optional":
+			base.ClientAuth = tls.VerifyClientCertIfGiven
+		default:
+			return nil, errors.Errorf("unsupported client auth %q", cfg.ClientAuth)
+		}
+	}
+
+	r := &certReloader{cfg: cfg, base: base}
+
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// tlsConfig returns the config for the server, which always uses the latest loaded certificates.
+func (r *certReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.base.MinVersion,
+		NextProtos: r.base.NextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			return r.current, nil
+		},
+	}
+}
+
+// reload loads the files if their contents changed. It reports whether the certificates were replaced.
+// If loading fails, the previous certificates are kept.
+func (r *certReloader) reload() (bool, error) {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+
+	contents := make([][]byte, len(files))
+
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to read TLS file")
+		}
+
+		contents[i] = content
+	}
+
+	r.mu.RLock()
+	unchanged := r.contents != nil && equalContents(r.contents, contents)
+	r.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(contents[0], contents[1])
+	if err != nil {
+		return false, errors.Wrap(err, "failed to load TLS certificate")
+	}
+
+	cfg := r.base.Clone()
+	cfg.Certificates = []tls.Certificate{cert}
+
+	if r.cfg.ClientCAFile != "" {
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(contents[2]) {
+			return false, errors.New("failed to load client CA: no certificates found")
+		}
+
+		cfg.ClientCAs = pool
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.current = cfg
+	r.expiry = cert.Leaf.NotAfter
+	r.contents = contents
+
+	return true, nil
+}
+
+// certificateExpiry returns the expiry time of the loaded certificate.
+func (r *certReloader) certificateExpiry() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.expiry
+}
+
+// watch reloads the certificates periodically and on SIGHUP, until the context is done.
+func (r *certReloader) watch(ctx context.Context) {
+	interval := r.cfg.ReloadInterval.Duration()
+	if interval == 0 {
+		interval = defaultReloadInterval
+	}
+
+	var tick <-chan time.Time
+
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	hup := make(chan os.Signal, 1)
+
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-hup:
+		}
+
+		reloaded, err := r.reload()
+		if err != nil {
+			slog.Warn().Err(err).Msg("failed to reload TLS certificates")
+
+			continue
+		}
+
+		if reloaded {
+			slog.Info().Time("expiry", r.certificateExpiry()).Msg("reloaded TLS certificates")
+		}
+	}
+}
+
+func equalContents(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,219 @@
+package httpd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert, notAfter time.Time) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	signer, signerKey := template, key
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (c *testCert) write(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	require.NoError(t, os.WriteFile(certFile, c.pem, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+func TestServer_TLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil, time.Now().Add(24*time.Hour))
+	serverCert := newTestCert(t, "server", ca, time.Now().Add(time.Hour))
+	certFile, keyFile := serverCert.write(t, dir)
+
+	ts := startTestServer(t, Config{
+		Interface: "127.0.0.1",
+		TLS:       TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"},
+	}, acceptHandler)
+
+	require.WithinDuration(t, serverCert.cert.NotAfter, ts.CertificateExpiry(), time.Second)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		ForceAttemptHTTP2: true,
+	}}
+
+	rsp, err := client.Get("https://" + ts.Address())
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, rsp.StatusCode)
+	require.Equal(t, 2, rsp.ProtoMajor)
+	require.Equal(t, uint16(tls.VersionTLS13), rsp.TLS.Version)
+	require.NoError(t, rsp.Body.Close())
+
+	// replace the certificate and reload
+	renewed := newTestCert(t, "server", ca, time.Now().Add(48*time.Hour))
+	renewed.write(t, dir)
+
+	reloaded, err := ts.certs.reload()
+
+	require.NoError(t, err)
+	require.True(t, reloaded)
+	require.WithinDuration(t, renewed.cert.NotAfter, ts.CertificateExpiry(), time.Second)
+
+	reloaded, err = ts.certs.reload()
+
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	// a broken file keeps the previous certificate
+	require.NoError(t, os.WriteFile(certFile, []byte("garbage"), 0o600))
+
+	_, err = ts.certs.reload()
+
+	require.Error(t, err)
+	require.WithinDuration(t, renewed.cert.NotAfter, ts.CertificateExpiry(), time.Second)
+}
+
+func TestServer_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil, time.Now().Add(24*time.Hour))
+	certFile, keyFile := newTestCert(t, "server", ca, time.Now().Add(time.Hour)).write(t, dir)
+	caFile := filepath.Join(dir, "ca.crt")
+
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	clientCert := newTestCert(t, "client", ca, time.Now().Add(time.Hour)).tlsCertificate()
+
+	get := func(ts *Server, certs ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:    pool,
+				MinVersion: tls.VersionTLS12,
+				// send the certificate even if it is not signed by an acceptable CA
+				GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					if len(certs) == 0 {
+						return &tls.Certificate{}, nil
+					}
+
+					return &certs[0], nil
+				},
+			},
+		}}
+
+		return client.Get("https://" + ts.Address())
+	}
+
+	t.Run("require", func(t *testing.T) {
+		ts := startTestServer(t, Config{
+			Interface: "127.0.0.1",
+			TLS:       TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, CipherPolicy: "modern"},
+		}, acceptHandler)
+
+		rsp, err := get(ts, clientCert)
+
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, rsp.StatusCode)
+		require.NoError(t, rsp.Body.Close())
+
+		_, err = get(ts)
+		require.Error(t, err)
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		ts := startTestServer(t, Config{
+			Interface: "127.0.0.1",
+			TLS:       TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, ClientAuth: "optional"},
+		}, acceptHandler)
+
+		rsp, err := get(ts)
+
+		require.NoError(t, err)
+		require.NoError(t, rsp.Body.Close())
+
+		untrusted := newTestCert(t, "other", nil, time.Now().Add(time.Hour)).tlsCertificate()
+
+		_, err = get(ts, untrusted)
+		require.Error(t, err)
+	})
+}
+
+func TestNewServer_TLSErrors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := newTestCert(t, "server", nil, time.Now().Add(time.Hour)).write(t, dir)
+
+	tests := map[string]TLSConfig{
+		"missing-file":  {CertFile: filepath.Join(dir, "missing"), KeyFile: keyFile},
+		"version":       {CertFile: certFile, KeyFile: keyFile, MinVersion: "1.0"},
+		"cipher-policy": {CertFile: certFile, KeyFile: keyFile, CipherPolicy: "weak"},
+		"client-auth":   {CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile, ClientAuth: "maybe"},
+		"client-ca":     {CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile},
+	}
+
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewServer(Config{Interface: "127.0.0.1", TLS: cfg}, http.NotFoundHandler())
+
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package httpd
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrSocketInUse is returned when the unix socket is used by another running server.
+var ErrSocketInUse = errors.New("socket in use")
+
+// unixScheme prefixes unix socket addresses returned by Server.Address, as accepted by hostutil.ParseAddress.
+const unixScheme = "unix://"
+
+// listenUnix listens on the unix socket of the config, and applies its mode and group.
+// The socket file is removed when the listener is closed.
+func listenUnix(ctx context.Context, cfg Config) (net.Listener, error) {
+	if err := removeStaleSocket(ctx, cfg.Socket); err != nil {
+		return nil, err
+	}
+
+	var lc net.ListenConfig
+
+	ln, err := lc.Listen(ctx, "unix", cfg.Socket)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to bind HTTP server to socket")
+	}
+
+	if err := applySocketPermissions(cfg); err != nil {
+		_ = ln.Close()
+
+		return nil, err
+	}
+
+	return ln, nil
+}
+
+//nolint:gochecknoglobals // replaced in tests
+var dialSocket = func(ctx context.Context, path string) (net.Conn, error) {
+	const probeTimeout = time.Second
+
+	dialer := net.Dialer{Timeout: probeTimeout}
+
+	return dialer.DialContext(ctx, "unix", path)
+}
+
+// removeStaleSocket removes the socket file if no server listens on it. The file is removed only when
+// the connection is refused, as a busy server may time out or fail with EAGAIN when its backlog is full.
+func removeStaleSocket(ctx context.Context, path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to check socket")
+	}
+
+	if info.Mode().Type() != fs.ModeSocket {
+		return errors.Errorf("%s exists and is not a socket", path)
+	}
+
+	conn, err := dialSocket(ctx, path)
+
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		_ = conn.Close()
+
+		return errors.Wrap(ErrSocketInUse, path)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		if err := os.Remove(path); err != nil {
+			return errors.Wrap(err, "failed to remove stale socket")
+		}
+
+		return nil
+	case errors.Is(err, syscall.EAGAIN), errors.As(err, &netErr) && netErr.Timeout():
+		return errors.Wrap(ErrSocketInUse, path)
+	default:
+		return errors.Wrap(err, "failed to check socket")
+	}
+}
+
+func applySocketPermissions(cfg Config) error {
+	if cfg.SocketMode != "" {
+		mode, err := strconv.ParseUint(cfg.SocketMode, 8, 32)
+		if err != nil {
+			return errors.Errorf("invalid socket mode %q", cfg.SocketMode)
+		}
+
+		if err := os.Chmod(cfg.Socket, fs.FileMode(mode)); err != nil {
+			return errors.Wrap(err, "failed to set socket mode")
+		}
+	}
+
+	if cfg.SocketGroup != "" {
+		gid, err := lookupGroup(cfg.SocketGroup)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Chown(cfg.Socket, -1, gid); err != nil {
+			return errors.Wrap(err, "failed to set socket group")
+		}
+	}
+
+	return nil
+}
+
+// lookupGroup returns the ID of the group given by name or ID.
+func lookupGroup(group string) (int, error) {
+	if gid, err := strconv.Atoi(group); err == nil {
+		return gid, nil
+	}
+
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to look up socket group")
+	}
+
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return 0, errors.Errorf("group %s has non-numeric ID %q", group, g.Gid)
+	}
+
+	return gid, nil
+}
+
+// listenerAddress returns the address of the listener, prefixed with the scheme for unix sockets.
+func listenerAddress(ln net.Listener) string {
+	addr := ln.Addr()
+
+	if addr.Network() == "unix" {
+		return unixScheme + addr.String()
+	}
+
+	return addr.String()
+}
+
+// keepSocketFile stops the listener from removing its socket file when closed, as the socket
+// is still used by the process the listener was handed off to.
+func keepSocketFile(ln net.Listener) {
+	if unixListener, ok := ln.(*net.UnixListener); ok {
+		unixListener.SetUnlinkOnClose(false)
+	}
+}
@@ -0,0 +1,157 @@
+//go:build unix
+
+package httpd
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/hostutil"
+	"github.com/stretchr/testify/require"
+)
+
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func TestServer_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "httpd.sock")
+
+	ts, err := NewServer(Config{
+		Socket:      path,
+		SocketMode:  "0660",
+		SocketGroup: strconv.Itoa(os.Getgid()),
+	}, acceptHandler)
+
+	require.NoError(t, err)
+	require.Equal(t, "unix://"+path, ts.Address())
+
+	address, err := hostutil.ParseAddress(ts.Address())
+
+	require.NoError(t, err)
+	require.Equal(t, "unix", address.Network())
+	require.Equal(t, path, address.Addr())
+
+	info, err := os.Stat(path)
+
+	require.NoError(t, err)
+	require.Equal(t, fs.ModeSocket, info.Mode().Type())
+	require.Equal(t, fs.FileMode(0o660), info.Mode().Perm())
+
+	errorCh := make(chan error, 1)
+
+	go func() { errorCh <- ts.Run() }()
+
+	rsp, err := unixClient(path).Get("http://localhost/")
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, rsp.StatusCode)
+	require.NoError(t, rsp.Body.Close())
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, ts.Stop(ctx))
+	require.NoError(t, <-errorCh)
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestServer_UnixSocketUnbind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "httpd.sock")
+
+	ts, err := NewServer(Config{Socket: path}, acceptHandler)
+
+	require.NoError(t, err)
+	require.NoError(t, ts.Unbind())
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestRemoveStaleSocket_DialErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "httpd.sock")
+
+	// a live server which does not accept connections at the moment
+	ln, err := net.Listen("unix", path)
+
+	require.NoError(t, err)
+
+	defer ln.Close()
+
+	tests := map[string]struct {
+		err     error
+		wantErr error
+	}{
+		"timeout":    {err: &net.OpError{Op: "dial", Net: "unix", Err: os.ErrDeadlineExceeded}, wantErr: ErrSocketInUse},
+		"backlog":    {err: &net.OpError{Op: "dial", Net: "unix", Err: os.NewSyscallError("connect", syscall.EAGAIN)}, wantErr: ErrSocketInUse},
+		"permission": {err: &net.OpError{Op: "dial", Net: "unix", Err: os.NewSyscallError("connect", syscall.EACCES)}, wantErr: syscall.EACCES},
+	}
+
+	defer func(dial func(context.Context, string) (net.Conn, error)) { dialSocket = dial }(dialSocket)
+
+	for name, tt := range tests {
+		dialSocket = func(context.Context, string) (net.Conn, error) { return nil, tt.err }
+
+		require.ErrorIs(t, removeStaleSocket(t.Context(), path), tt.wantErr, name)
+		require.FileExists(t, path, name)
+	}
+}
+
+func TestServer_StaleSocket(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "httpd.sock")
+
+	// a socket left by a crashed process
+	ln, err := net.Listen("unix", path)
+
+	require.NoError(t, err)
+
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	require.NoError(t, ln.Close())
+
+	ts, err := NewServer(Config{Socket: path}, acceptHandler)
+
+	require.NoError(t, err)
+
+	// the socket is in use now
+	_, err = NewServer(Config{Socket: path}, acceptHandler)
+	require.ErrorIs(t, err, ErrSocketInUse)
+
+	require.NoError(t, ts.Unbind())
+
+	// not a socket
+	file := filepath.Join(dir, "file")
+
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	_, err = NewServer(Config{Socket: file}, acceptHandler)
+	require.ErrorContains(t, err, "is not a socket")
+
+	_, err = NewServer(Config{Socket: path, SocketMode: "rw"}, acceptHandler)
+	require.ErrorContains(t, err, "invalid socket mode")
+
+	_, err = NewServer(Config{Socket: path, SocketGroup: "no-such-group-" + strings.Repeat("x", 8)}, acceptHandler)
+	require.Error(t, err)
+
+	// failed setup does not leave the socket behind
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
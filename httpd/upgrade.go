@@ -0,0 +1,222 @@
+package httpd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/exopulse/go-kit/slog"
+	"github.com/pkg/errors"
+)
+
+const (
+	// handoffPIDEnv holds the pid of the process handing its listeners off.
+	handoffPIDEnv = "HTTPD_HANDOFF_PID"
+
+	// handoffReadyFDEnv holds the descriptor the new process reports its readiness to.
+	handoffReadyFDEnv = "HTTPD_HANDOFF_READY_FD"
+
+	upgradeTimeout = 30 * time.Second
+)
+
+// ErrNoListeners is returned when there are no listeners to hand off.
+var ErrNoListeners = errors.New("no listeners to hand off")
+
+// upgradeCommand returns the command starting the new instance of the executable.
+//
+//nolint:gochecknoglobals // replaced in tests
+var upgradeCommand = func() (*exec.Cmd, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find executable")
+	}
+
+	return exec.Command(path, os.Args[1:]...), nil //nolint:gosec // restarting self
+}
+
+// Upgrade starts a new instance of the executable with the same arguments, passing it the listeners of the
+// servers, and waits until it reports readiness with NotifyReady. The new instance takes the listeners over when
+// it creates servers with the same listener name, or with the same interface and port. Both instances accept
+// connections until the servers of this one are unbound, so the servers should be shut down after the upgrade.
+// Servers created with WithListener are not handed off.
+//
+// RunGracefully and Group.Run upgrade on SIGUSR2, where supported.
+func Upgrade(ctx context.Context, servers ...*Server) error {
+	var (
+		files []*os.File
+		names []string
+	)
+
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+
+	for _, s := range servers {
+		if s.name == "" {
+			continue
+		}
+
+		for _, ln := range s.listeners {
+			filer, ok := ln.(interface{ File() (*os.File, error) })
+			if !ok {
+				return errors.Errorf("listener %s cannot be handed off", ln.Addr())
+			}
+
+			file, err := filer.File()
+			if err != nil {
+				return errors.Wrap(err, "failed to get listener file")
+			}
+
+			files = append(files, file)
+			names = append(names, s.name)
+		}
+	}
+
+	if len(files) == 0 {
+		return ErrNoListeners
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return errors.Wrap(err, "failed to create readiness pipe")
+	}
+
+	defer readyR.Close() //nolint:errcheck // read end only
+
+	cmd, err := upgradeCommand()
+	if err != nil {
+		_ = readyW.Close()
+
+		return err
+	}
+
+	cmd.Env = append(handoffEnviron(),
+		"LISTEN_FDS="+strconv.Itoa(len(files)),
+		"LISTEN_FDNAMES="+strings.Join(names, ":"),
+		handoffPIDEnv+"="+strconv.Itoa(os.Getpid()),
+		handoffReadyFDEnv+"="+strconv.Itoa(listenFDsStart+len(files)),
+	)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = append(files, readyW) //nolint:gocritic // files are closed separately
+
+	err = cmd.Start()
+
+	// the child holds its own copy of the write end
+	_ = readyW.Close()
+
+	if err != nil {
+		return errors.Wrap(err, "failed to start new instance")
+	}
+
+	// reap the child when it exits
+	exited := make(chan struct{})
+
+	go func() {
+		_ = cmd.Wait()
+
+		close(exited)
+	}()
+
+	readyCh := make(chan error, 1)
+
+	go func() {
+		_, err := readyR.Read(make([]byte, 1))
+
+		readyCh <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, upgradeTimeout)
+	defer cancel()
+
+	select {
+	case err := <-readyCh:
+		if err != nil {
+			return errors.Wrap(err, "new instance exited before reporting readiness")
+		}
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+
+		<-exited
+
+		return errors.Wrap(ctx.Err(), "new instance did not report readiness")
+	}
+
+	for _, s := range servers {
+		if s.name != "" {
+			for _, ln := range s.listeners {
+				keepSocketFile(ln)
+			}
+		}
+	}
+
+	slog.Info().Int("pid", cmd.Process.Pid).Strs("listeners", names).Msg("listeners handed off to new instance")
+
+	return nil
+}
+
+// NotifyReady reports readiness to the process which started this one with Upgrade.
+// It does nothing if the process was not started by an upgrade.
+// RunGracefully and Group.Run call it once the servers run.
+func NotifyReady() error {
+	loadActivation()
+
+	activation.mu.Lock()
+	fd := activation.readyFD
+	activation.readyFD = ""
+	activation.mu.Unlock()
+
+	if fd == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return errors.Errorf("invalid readiness descriptor %q", fd)
+	}
+
+	file := os.NewFile(uintptr(n), "ready")
+	defer file.Close() //nolint:errcheck // the parent only waits for the first byte
+
+	if _, err := file.Write([]byte{1}); err != nil {
+		return errors.Wrap(err, "failed to report readiness")
+	}
+
+	return nil
+}
+
+// notifyUpgrade relays upgrade signals to the channel until stop is called.
+func notifyUpgrade() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal, 1)
+
+	if len(upgradeSignals) == 0 {
+		return ch, func() {}
+	}
+
+	signal.Notify(ch, upgradeSignals...)
+
+	return ch, func() { signal.Stop(ch) }
+}
+
+// handoffEnviron returns the environment without the variables describing passed listeners.
+func handoffEnviron() []string {
+	environ := os.Environ()
+	filtered := environ[:0]
+
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+
+		switch key {
+		case "LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES", handoffPIDEnv, handoffReadyFDEnv:
+		default:
+			filtered = append(filtered, kv)
+		}
+	}
+
+	return filtered
+}
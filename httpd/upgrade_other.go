@@ -0,0 +1,10 @@
+//go:build !unix
+
+package httpd
+
+import "os"
+
+// upgradeSignals trigger an upgrade in RunGracefully and Group.Run. Upgrades are not triggered by signals on this platform.
+//
+//nolint:gochecknoglobals // constant list
+var upgradeSignals []os.Signal
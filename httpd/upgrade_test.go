@@ -0,0 +1,127 @@
+//go:build unix
+
+package httpd
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func pidHandler(quit context.CancelFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/quit" && quit != nil {
+			quit()
+		}
+
+		_, _ = w.Write([]byte(strconv.Itoa(os.Getpid())))
+	})
+}
+
+func getPid(t *testing.T, url string) string {
+	t.Helper()
+
+	rsp, err := http.Get(url)
+
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(rsp.Body)
+
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+
+	return string(body)
+}
+
+func TestRunGracefully_Upgrade(t *testing.T) {
+	t.Setenv("HTTPD_UPGRADE_HELPER", "1")
+
+	previous := upgradeCommand
+
+	t.Cleanup(func() { upgradeCommand = previous })
+
+	upgradeCommand = func() (*exec.Cmd, error) {
+		return exec.Command(os.Args[0], "-test.run=^TestUpgradeHelper$"), nil //nolint:gosec // test binary
+	}
+
+	ts, err := NewServer(Config{Interface: "127.0.0.1"}, pidHandler(nil))
+
+	require.NoError(t, err)
+
+	doneCh := make(chan error, 1)
+
+	go func() { doneCh <- RunGracefully(t.Context(), ts) }()
+
+	waitReady(t, ts)
+
+	url := "http://" + ts.Address()
+	pid := strconv.Itoa(os.Getpid())
+
+	require.Equal(t, pid, getPid(t, url))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+
+	select {
+	case err := <-doneCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for upgrade")
+	}
+
+	// the same address is served by the new instance
+	child := getPid(t, url)
+
+	require.NotEqual(t, pid, child)
+	require.Equal(t, child, getPid(t, url+"/quit"))
+}
+
+// TestUpgradeHelper runs in the new instance started by TestRunGracefully_Upgrade.
+func TestUpgradeHelper(t *testing.T) {
+	if os.Getenv("HTTPD_UPGRADE_HELPER") != "1" || os.Getenv(handoffPIDEnv) == "" {
+		t.Skip("helper process only")
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	ts, err := NewServer(Config{Interface: "127.0.0.1"}, pidHandler(cancel))
+
+	require.NoError(t, err)
+	require.NoError(t, RunGracefully(ctx, ts))
+}
+
+func TestUpgrade_NoListeners(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	require.NoError(t, err)
+
+	ts, err := NewServer(Config{}, acceptHandler, WithListener(ln))
+
+	require.NoError(t, err)
+	require.ErrorIs(t, Upgrade(t.Context(), ts), ErrNoListeners)
+	require.NoError(t, ts.Unbind())
+}
+
+func TestUpgrade_ChildExits(t *testing.T) {
+	previous := upgradeCommand
+
+	t.Cleanup(func() { upgradeCommand = previous })
+
+	upgradeCommand = func() (*exec.Cmd, error) {
+		return exec.Command("true"), nil
+	}
+
+	ts, err := NewServer(Config{Interface: "127.0.0.1"}, acceptHandler)
+
+	require.NoError(t, err)
+	require.ErrorContains(t, Upgrade(t.Context(), ts), "exited before reporting readiness")
+	require.NoError(t, ts.Unbind())
+}
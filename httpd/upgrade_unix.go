@@ -0,0 +1,13 @@
+//go:build unix
+
+package httpd
+
+import (
+	"os"
+	"syscall"
+)
+
+// upgradeSignals trigger an upgrade in RunGracefully and Group.Run.
+//
+//nolint:gochecknoglobals // constant list
+var upgradeSignals = []os.Signal{syscall.SIGUSR2}
@@ -0,0 +1,2 @@
+// Package configz provides a REST route serving the effective configuration of a running instance.
+package configz
@@ -0,0 +1,41 @@
+package configz
+
+import (
+	"net/http"
+
+	"github.com/exopulse/go-kit/envconf"
+	"github.com/gin-gonic/gin"
+)
+
+// Route serves the snapshot of a configuration parsed with envconf.
+// Secret fields are redacted. The snapshot checksum is sent in the ETag header.
+type Route struct {
+	cfg        any
+	provenance *envconf.Provenance
+}
+
+// New creates a new Route for the configuration cfg, which has to be a pointer to a struct,
+// and the provenance recorded when parsing it with envconf.WithProvenance.
+func New(cfg any, provenance *envconf.Provenance) *Route {
+	return &Route{
+		cfg:        cfg,
+		provenance: provenance,
+	}
+}
+
+// RegisterRoutes implements router.Route interface.
+func (r *Route) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("", r.get)
+}
+
+func (r *Route) get(c *gin.Context) {
+	snapshot, err := envconf.TakeSnapshot(r.cfg, r.provenance)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusInternalServerError, err)
+
+		return
+	}
+
+	c.Header("ETag", `"`+snapshot.Checksum+`"`)
+	c.JSON(http.StatusOK, snapshot)
+}
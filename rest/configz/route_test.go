@@ -0,0 +1,61 @@
+package configz
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/exopulse/go-kit/envconf"
+	"github.com/exopulse/go-kit/rest/router"
+	"github.com/stretchr/testify/require"
+)
+
+type config struct {
+	Port     string `env:"CONFIGZ_PORT" envDefault:"8080"`
+	Password string `env:"CONFIGZ_PASSWORD,secret"`
+}
+
+func TestRoute(t *testing.T) {
+	t.Setenv("CONFIGZ_PASSWORD", "s3cr3t-value")
+
+	var provenance envconf.Provenance
+
+	cfg := config{}
+
+	require.NoError(t, envconf.Parse(&cfg, envconf.WithProvenance(&provenance)))
+
+	rtr := router.New()
+
+	rtr.RegisterRoutes("/configz", New(&cfg, &provenance))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/configz", nil)
+
+	rtr.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotContains(t, w.Body.String(), "s3cr3t-value")
+
+	var snapshot envconf.Snapshot
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+	require.Equal(t, `"`+snapshot.Checksum+`"`, w.Header().Get("ETag"))
+	require.Equal(t, []envconf.FieldSnapshot{
+		{Field: "Port", Key: "CONFIGZ_PORT", Value: "8080", Source: "default"},
+		{Field: "Password", Key: "CONFIGZ_PASSWORD", Value: envconf.Redacted, Source: "env", Secret: true},
+	}, snapshot.Fields)
+}
+
+func TestRoute_Error(t *testing.T) {
+	rtr := router.New()
+
+	rtr.RegisterRoutes("/configz", New(config{}, nil))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/configz", nil)
+
+	rtr.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+}
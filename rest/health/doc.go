@@ -0,0 +1,5 @@
+// Package health provides liveness and readiness endpoints backed by pluggable health checks.
+//
+// Readiness does not follow the state of HTTP servers on its own. Register each httpd.Server with
+// Registry.AddReadier, so load balancers stop routing traffic as soon as the server starts shutting down.
+package health
@@ -0,0 +1,291 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Status of a check or a report.
+type Status string
+
+// Statuses.
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// ErrNotReady is returned by ReadyCheck when the component is not ready.
+var ErrNotReady = errors.New("not ready")
+
+// Check checks the health of a component. It returns nil if the component is healthy.
+type Check func(ctx context.Context) error
+
+// Readier is implemented by components reporting their readiness, like httpd.Server.
+type Readier interface {
+	Ready() bool
+}
+
+// ReadyCheck returns a check failing when the component is not ready.
+// For an httpd.Server, it fails as soon as the server starts shutting down.
+func ReadyCheck(r Readier) Check {
+	return func(context.Context) error {
+		if !r.Ready() {
+			return ErrNotReady
+		}
+
+		return nil
+	}
+}
+
+// CheckOption configures a check.
+type CheckOption func(c *check)
+
+// WithTimeout sets the timeout of the check. The default is 5 seconds.
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// WithNonCritical makes the check non-critical. Failure of a non-critical check is reported,
+// but does not change the overall status.
+func WithNonCritical() CheckOption {
+	return func(c *check) {
+		c.critical = false
+	}
+}
+
+// WithCacheInterval caches the result of the check for the interval, so that expensive checks
+// are not run on every probe. By default, results are not cached.
+func WithCacheInterval(interval time.Duration) CheckOption {
+	return func(c *check) {
+		c.interval = interval
+	}
+}
+
+// Result is the result of a single check.
+type Result struct {
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Latency   string    `json:"latency"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Report is the aggregated result of checks. It is down if any critical check is down.
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+type check struct {
+	name     string
+	fn       Check
+	timeout  time.Duration
+	critical bool
+	interval time.Duration
+
+	mu       sync.Mutex
+	result   *Result
+	inFlight *flight
+}
+
+// flight is a run of a check. Concurrent probes share the run, so a check which ignores
+// its context does not pile up goroutines.
+type flight struct {
+	ctx     context.Context //nolint:containedctx // shared by the probes waiting for the run
+	done    chan struct{}
+	result  Result
+	started time.Time
+}
+
+// Registry holds liveness and readiness checks.
+type Registry struct {
+	mu        sync.RWMutex
+	liveness  []*check
+	readiness []*check
+	now       func() time.Time
+}
+
+// NewRegistry creates a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{now: time.Now}
+}
+
+// AddLivenessCheck registers a named liveness check. Liveness checks should only fail
+// when the process cannot recover without a restart.
+func (r *Registry) AddLivenessCheck(name string, fn Check, opts ...CheckOption) {
+	c := newCheck(name, fn, opts)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.liveness = append(r.liveness, c)
+}
+
+// AddReadinessCheck registers a named readiness check. Readiness checks fail when the instance
+// cannot serve traffic, e.g. when a dependency is unavailable or the server is shutting down.
+func (r *Registry) AddReadinessCheck(name string, fn Check, opts ...CheckOption) {
+	c := newCheck(name, fn, opts)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.readiness = append(r.readiness, c)
+}
+
+// AddReadier registers a readiness check of a component reporting its readiness, see ReadyCheck.
+// Every httpd.Server serving traffic has to be registered, so the readiness flips to down
+// as soon as the server starts shutting down.
+func (r *Registry) AddReadier(name string, readier Readier, opts ...CheckOption) {
+	r.AddReadinessCheck(name, ReadyCheck(readier), opts...)
+}
+
+// Liveness runs the liveness checks.
+func (r *Registry) Liveness(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.liveness
+	r.mu.RUnlock()
+
+	return r.run(ctx, checks)
+}
+
+// Readiness runs the readiness checks.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.readiness
+	r.mu.RUnlock()
+
+	return r.run(ctx, checks)
+}
+
+func newCheck(name string, fn Check, opts []CheckOption) *check {
+	c := &check{
+		name:     name,
+		fn:       fn,
+		timeout:  defaultTimeout,
+		critical: true,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// run runs the checks concurrently, and aggregates the results in the order of registration.
+func (r *Registry) run(ctx context.Context, checks []*check) Report {
+	report := Report{
+		Status: StatusUp,
+		Checks: make([]Result, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+
+	for i, c := range checks {
+		wg.Go(func() {
+			report.Checks[i] = c.run(ctx, r.now)
+		})
+	}
+
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Critical && result.Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (c *check) run(ctx context.Context, now func() time.Time) Result {
+	c.mu.Lock()
+
+	if c.result != nil && now().Sub(c.result.CheckedAt) < c.interval {
+		defer c.mu.Unlock()
+
+		return *c.result
+	}
+
+	f := c.inFlight
+	if f == nil {
+		f = c.start(ctx, now)
+	}
+
+	c.mu.Unlock()
+
+	// the check runs in its own goroutine, so the timeout holds even if the check ignores its context
+	select {
+	case <-f.done:
+		return f.result
+	case <-f.ctx.Done():
+		result := c.newResult(f.started, now(), f.ctx.Err())
+
+		c.mu.Lock()
+		c.result = &result
+		c.mu.Unlock()
+
+		return result
+	case <-ctx.Done():
+		// the probe gave up, which says nothing about the check, so the result is not cached
+		return c.newResult(f.started, now(), ctx.Err())
+	}
+}
+
+// start runs the check in a new goroutine, which caches the result once the check returns.
+// It has to be called with the mutex held.
+func (c *check) start(ctx context.Context, now func() time.Time) *flight {
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+
+	f := &flight{
+		ctx:     runCtx,
+		done:    make(chan struct{}),
+		started: now(),
+	}
+
+	c.inFlight = f
+
+	go func() {
+		defer cancel()
+
+		err := c.fn(runCtx)
+		if err == nil && runCtx.Err() != nil {
+			// the check succeeded too late
+			err = runCtx.Err()
+		}
+
+		f.result = c.newResult(f.started, now(), err)
+		close(f.done)
+
+		c.mu.Lock()
+		c.result = &f.result
+		c.inFlight = nil
+		c.mu.Unlock()
+	}()
+
+	return f
+}
+
+func (c *check) newResult(started, finished time.Time, err error) Result {
+	result := Result{
+		Name:      c.name,
+		Status:    StatusUp,
+		Critical:  c.critical,
+		Latency:   finished.Sub(started).String(),
+		CheckedAt: started,
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type readier bool
+
+func (r readier) Ready() bool { return bool(r) }
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.AddReadinessCheck("db", func(context.Context) error { return nil })
+	registry.AddReadinessCheck("cache", func(context.Context) error { return errors.New("unreachable") }, WithNonCritical())
+	registry.AddLivenessCheck("deadlock", func(context.Context) error { return nil })
+
+	report := registry.Readiness(t.Context())
+
+	require.Equal(t, StatusUp, report.Status)
+	require.Len(t, report.Checks, 2)
+	require.Equal(t, "db", report.Checks[0].Name)
+	require.Equal(t, StatusUp, report.Checks[0].Status)
+	require.True(t, report.Checks[0].Critical)
+	require.NotEmpty(t, report.Checks[0].Latency)
+	require.Equal(t, StatusDown, report.Checks[1].Status)
+	require.Equal(t, "unreachable", report.Checks[1].Error)
+
+	registry.AddReadier("server", readier(false))
+
+	report = registry.Readiness(t.Context())
+
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, ErrNotReady.Error(), report.Checks[2].Error)
+
+	report = registry.Liveness(t.Context())
+
+	require.Equal(t, StatusUp, report.Status)
+	require.Len(t, report.Checks, 1)
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.AddLivenessCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond))
+
+	report := registry.Liveness(t.Context())
+
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestRegistry_TimeoutIgnored(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	var calls atomic.Int32
+
+	registry := NewRegistry()
+	registry.AddLivenessCheck("stuck", func(context.Context) error {
+		calls.Add(1)
+
+		<-release
+
+		return nil
+	}, WithTimeout(10*time.Millisecond))
+
+	for range 3 {
+		report := registry.Liveness(t.Context())
+
+		require.Equal(t, StatusDown, report.Status)
+		require.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+	}
+
+	// probes share the stuck run instead of starting new ones
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestRegistry_ProbeCancelled(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.AddReadinessCheck("slow", func(context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+
+		return nil
+	}, WithCacheInterval(time.Minute))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	report := registry.Readiness(ctx)
+
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+
+	// the result of the check is cached once it finishes, not the error of the probe
+	report = registry.Readiness(t.Context())
+
+	require.Equal(t, StatusUp, report.Status)
+
+	report = registry.Readiness(t.Context())
+
+	require.Equal(t, StatusUp, report.Status)
+}
+
+func TestRegistry_CacheInterval(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	calls := 0
+
+	registry := NewRegistry()
+	registry.now = func() time.Time { return now }
+	registry.AddReadinessCheck("expensive", func(context.Context) error {
+		calls++
+
+		return nil
+	}, WithCacheInterval(time.Minute))
+
+	registry.Readiness(t.Context())
+	registry.Readiness(t.Context())
+
+	require.Equal(t, 1, calls)
+
+	now = now.Add(time.Minute)
+
+	registry.Readiness(t.Context())
+
+	require.Equal(t, 2, calls)
+}
@@ -0,0 +1,39 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route serves the liveness report at /healthz and the readiness report at /readyz.
+// Reports are sent as JSON, with status 200 if up and 503 if down.
+type Route struct {
+	registry *Registry
+}
+
+// New creates a new Route for the registry.
+func New(registry *Registry) *Route {
+	return &Route{registry: registry}
+}
+
+// RegisterRoutes implements router.Route interface.
+func (r *Route) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/healthz", func(c *gin.Context) {
+		writeReport(c, r.registry.Liveness(c.Request.Context()))
+	})
+	group.GET("/readyz", func(c *gin.Context) {
+		writeReport(c, r.registry.Readiness(c.Request.Context()))
+	})
+}
+
+func writeReport(c *gin.Context, report Report) {
+	status := http.StatusOK
+
+	if report.Status != StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
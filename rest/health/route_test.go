@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/httpd"
+	"github.com/exopulse/go-kit/rest/router"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoute(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddLivenessCheck("ping", func(context.Context) error { return nil })
+
+	rtr := router.New()
+
+	ts, err := httpd.NewServer(httpd.Config{Interface: "127.0.0.1"}, rtr)
+
+	require.NoError(t, err)
+
+	registry.AddReadier("server", ts)
+	rtr.RegisterRoutes("", New(registry))
+
+	get := func(path string) (int, Report) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+
+		rtr.ServeHTTP(w, req)
+
+		var report Report
+
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+
+		return w.Code, report
+	}
+
+	// not running yet
+	code, report := get("/readyz")
+
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, StatusDown, report.Status)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	doneCh := make(chan error, 1)
+
+	go func() { doneCh <- httpd.RunGracefully(ctx, ts) }()
+
+	require.Eventually(t, ts.Ready, 5*time.Second, 10*time.Millisecond)
+
+	code, report = get("/readyz")
+
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, StatusUp, report.Status)
+
+	code, report = get("/healthz")
+
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ping", report.Checks[0].Name)
+
+	cancel()
+
+	require.NoError(t, <-doneCh)
+
+	code, _ = get("/readyz")
+
+	require.Equal(t, http.StatusServiceUnavailable, code)
+}
@@ -0,0 +1,2 @@
+// Package ipfilter provides a Gin middleware restricting access by client IP address.
+package ipfilter
@@ -0,0 +1,110 @@
+package ipfilter
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/exopulse/go-kit/hostutil"
+	"github.com/exopulse/go-kit/rest/reqlog"
+	"github.com/gin-gonic/gin"
+)
+
+// Option configures the middleware.
+type Option func(f *filter)
+
+type filter struct {
+	policy  hostutil.IPPolicy
+	trusted *hostutil.IPSet
+	status  int
+}
+
+// WithTrustedProxies sets the proxies whose X-Forwarded-For header is trusted.
+// By default, no proxies are trusted and the client IP is taken from the connection.
+func WithTrustedProxies(proxies *hostutil.IPSet) Option {
+	return func(f *filter) {
+		f.trusted = proxies
+	}
+}
+
+// WithStatus sets the response status for rejected requests. The default is 403 Forbidden.
+func WithStatus(status int) Option {
+	return func(f *filter) {
+		f.status = status
+	}
+}
+
+// New creates a middleware rejecting requests from client IP addresses not allowed by the policy.
+func New(policy hostutil.IPPolicy, opts ...Option) gin.HandlerFunc {
+	f := &filter{
+		policy: policy,
+		status: http.StatusForbidden,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f.handle
+}
+
+func (f *filter) handle(c *gin.Context) {
+	ip, ok := ClientIP(c.Request, f.trusted)
+	if !ok || !f.policy.Allowed(ip) {
+		reqlog.RequestLogger(c).Debug().
+			Str("client_ip", ip.String()).
+			Str("remote_addr", c.Request.RemoteAddr).
+			Msg("request rejected by IP filter")
+
+		c.AbortWithStatus(f.status)
+
+		return
+	}
+
+	c.Next()
+}
+
+// ClientIP returns the IP address of the client which sent the request.
+// If the peer is a trusted proxy, the X-Forwarded-For header is walked from right to left,
+// and the first address which is not a trusted proxy is returned. It returns false if the
+// address cannot be determined, e.g. when a trusted proxy sends a malformed header.
+func ClientIP(r *http.Request, trusted *hostutil.IPSet) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	ip = ip.Unmap()
+
+	if !trusted.Contains(ip) {
+		return ip, true
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		entry := strings.TrimSpace(forwarded[i])
+		if entry == "" {
+			continue
+		}
+
+		hop, err := netip.ParseAddr(entry)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+
+		ip = hop.Unmap()
+
+		if !trusted.Contains(ip) {
+			break
+		}
+	}
+
+	return ip, true
+}
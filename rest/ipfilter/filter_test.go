@@ -0,0 +1,100 @@
+package ipfilter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/exopulse/go-kit/hostutil"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(New(
+		hostutil.IPPolicy{Allow: hostutil.MustParseIPSet("10.0.0.0/8,fd00::/8")},
+		WithTrustedProxies(hostutil.MustParseIPSet("192.168.0.1")),
+	))
+	engine.GET("/admin", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := map[string]struct {
+		remoteAddr string
+		forwarded  string
+		want       int
+	}{
+		"allowed":            {"10.1.2.3:1234", "", http.StatusOK},
+		"allowed-v6":         {"[fd00::1]:1234", "", http.StatusOK},
+		"denied":             {"203.0.113.1:1234", "", http.StatusForbidden},
+		"untrusted-spoof":    {"203.0.113.1:1234", "10.0.0.1", http.StatusForbidden},
+		"trusted-proxy":      {"192.168.0.1:1234", "10.0.0.1", http.StatusOK},
+		"trusted-proxy-deny": {"192.168.0.1:1234", "10.0.0.1, 203.0.113.1", http.StatusForbidden},
+		"trusted-no-header":  {"192.168.0.1:1234", "", http.StatusForbidden},
+		"malformed-header":   {"192.168.0.1:1234", "10.0.0.1, garbage", http.StatusForbidden},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			w := httptest.NewRecorder()
+
+			engine.ServeHTTP(w, req)
+
+			require.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestWithStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(New(hostutil.IPPolicy{Allow: hostutil.MustParseIPSet("10.0.0.0/8")}, WithStatus(http.StatusNotFound)))
+	engine.GET("/admin", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+
+	w := httptest.NewRecorder()
+
+	engine.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := hostutil.MustParseIPSet("192.168.0.0/24")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.168.0.1:1234"
+	req.Header.Add("X-Forwarded-For", "198.51.100.7, 10.0.0.1")
+	req.Header.Add("X-Forwarded-For", "192.168.0.2")
+
+	ip, ok := ClientIP(req, trusted)
+
+	require.True(t, ok)
+	require.Equal(t, netip.MustParseAddr("10.0.0.1"), ip)
+
+	// all hops are trusted, the leftmost one is the client
+	req.Header.Set("X-Forwarded-For", "192.168.0.3")
+
+	ip, ok = ClientIP(req, trusted)
+
+	require.True(t, ok)
+	require.Equal(t, netip.MustParseAddr("192.168.0.3"), ip)
+
+	req.RemoteAddr = "garbage"
+
+	_, ok = ClientIP(req, trusted)
+
+	require.False(t, ok)
+}
@@ -0,0 +1,26 @@
+package reqlog
+
+import (
+	"github.com/exopulse/go-kit/httpd"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// AddressFields returns a middleware adding the remote_addr field with the client address to the request logger.
+// For connections accepted with the PROXY protocol, the client address is the one sent by the load balancer,
+// and the proxy_addr field contains the address of the load balancer.
+func AddressFields() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		UpdateLogger(c, func(current *zerolog.Logger) zerolog.Logger {
+			fields := current.With().Str("remote_addr", c.Request.RemoteAddr)
+
+			if header, ok := httpd.ProxyHeaderFromContext(c.Request.Context()); ok && header.Proxy != nil {
+				fields = fields.Str("proxy_addr", header.Proxy.String())
+			}
+
+			return fields.Logger()
+		})
+
+		c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+package reqlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/exopulse/go-kit/hostutil"
+	"github.com/exopulse/go-kit/httpd"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func newLoggingRouter(buf *bytes.Buffer) *gin.Engine {
+	router := gin.New()
+
+	router.Use(func(c *gin.Context) {
+		SetLogger(c, zerolog.New(buf))
+	}, AddressFields())
+
+	router.GET("/", func(c *gin.Context) {
+		RequestLogger(c).Info().Msg("test message")
+		c.Status(http.StatusNoContent)
+	})
+
+	return router
+}
+
+func TestAddressFields(t *testing.T) {
+	var buf bytes.Buffer
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:56324"
+
+	newLoggingRouter(&buf).ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Contains(t, buf.String(), `"remote_addr":"192.0.2.1:56324"`)
+	require.NotContains(t, buf.String(), "proxy_addr")
+}
+
+func TestAddressFields_ProxyProtocol(t *testing.T) {
+	var buf bytes.Buffer
+
+	server, err := httpd.NewServer(httpd.Config{
+		Interface: "127.0.0.1",
+		Proxy: httpd.ProxyConfig{
+			Enabled:        true,
+			TrustedSources: hostutil.MustParseIPSet("127.0.0.1/32"),
+		},
+	}, newLoggingRouter(&buf))
+
+	require.NoError(t, err)
+
+	go func() { _ = server.Run() }()
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		require.NoError(t, server.Stop(ctx))
+	})
+
+	conn, err := net.Dial("tcp", server.Address())
+
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	_, err = io.WriteString(conn, "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n"+
+		"GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+
+	require.NoError(t, err)
+
+	rsp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	require.Equal(t, http.StatusNoContent, rsp.StatusCode)
+
+	require.Contains(t, buf.String(), `"remote_addr":"192.0.2.1:56324"`)
+	require.Contains(t, buf.String(), `"proxy_addr":"`+conn.LocalAddr().String()+`"`)
+}
@@ -60,3 +60,9 @@ func WithContext(ctx context.Context) context.Context {
 func FromContext(ctx context.Context) *zerolog.Logger {
 	return zerolog.Ctx(ctx)
 }
+
+// SetBaseFields adds the fields to every log line written by the global logger,
+// e.g. the host identity fields. It should be called once at startup, before logging starts.
+func SetBaseFields(fields map[string]any) {
+	Global = Global.With().Fields(fields).Logger()
+}
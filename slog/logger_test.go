@@ -1,9 +1,11 @@
 package slog
 
 import (
+	"bytes"
 	"context"
 	"testing"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,3 +37,18 @@ func TestContext(t *testing.T) {
 		require.NotNil(t, loggerFromCtx)
 	})
 }
+
+func TestSetBaseFields(t *testing.T) {
+	previous := Global
+
+	t.Cleanup(func() { Global = previous })
+
+	var buf bytes.Buffer
+
+	Global = zerolog.New(&buf)
+
+	SetBaseFields(map[string]any{"host": "web-1"})
+	Info().Msg("test message")
+
+	require.Contains(t, buf.String(), `"host":"web-1"`)
+}